@@ -3,6 +3,8 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
 	"runtime"
 
 	"github.com/composer22/pzscan/scanner"
@@ -14,6 +16,21 @@ func main() {
 	var procs int
 	var maxRunMin int
 	var maxWorkers int
+	var reportFile string
+	var checkpointFile string
+	var checkpointSec int
+	var resume bool
+	var storeFile string
+	var historyFile string
+	var maxBodySize string
+	var timeoutSec int
+	var robotsAgent string
+	var respectNofollow bool
+	var nearDuplicates bool
+	var graphFile string
+	var graphPagesOnly bool
+	var graphByDir bool
+	var graphColor bool
 	var showVersion bool
 
 	flag.StringVar(&hostname, "H", scanner.DefaultHostname, "Hostname to scan.")
@@ -24,6 +41,36 @@ func main() {
 	flag.IntVar(&maxRunMin, "--minutes", scanner.DefaultMaxMin, "Maximum minutes you want to run this routine.")
 	flag.IntVar(&maxWorkers, "W", scanner.DefaultMaxWorkers, "Maximum Job Workers.")
 	flag.IntVar(&maxWorkers, "--workers", scanner.DefaultMaxWorkers, "Maximum Job Workers.")
+	flag.StringVar(&reportFile, "r", "", "File to write the json report to.")
+	flag.StringVar(&reportFile, "--report", "", "File to write the json report to.")
+	flag.StringVar(&checkpointFile, "c", "", "File to save the state of the scan to.")
+	flag.StringVar(&checkpointFile, "--checkpoint", "", "File to save the state of the scan to.")
+	flag.IntVar(&checkpointSec, "i", scanner.DefaultCheckpointSec, "Seconds between checkpoints.")
+	flag.IntVar(&checkpointSec, "--interval", scanner.DefaultCheckpointSec, "Seconds between checkpoints.")
+	flag.BoolVar(&resume, "R", false, "Resume the scan from the checkpoint file.")
+	flag.BoolVar(&resume, "--resume", false, "Resume the scan from the checkpoint file.")
+	flag.StringVar(&storeFile, "s", "", "Database file to hold the crawl on disk.")
+	flag.StringVar(&storeFile, "--store", "", "Database file to hold the crawl on disk.")
+	flag.StringVar(&historyFile, "I", "", "Database file of previous runs for incremental scans.")
+	flag.StringVar(&historyFile, "--history", "", "Database file of previous runs for incremental scans.")
+	flag.StringVar(&maxBodySize, "z", scanner.DefaultMaxBodySize, "Maximum body size for each URL type.")
+	flag.StringVar(&maxBodySize, "--max-size", scanner.DefaultMaxBodySize, "Maximum body size for each URL type.")
+	flag.IntVar(&timeoutSec, "t", scanner.DefaultTimeoutSec, "Seconds a request may take.")
+	flag.IntVar(&timeoutSec, "--timeout", scanner.DefaultTimeoutSec, "Seconds a request may take.")
+	flag.StringVar(&robotsAgent, "a", scanner.DefaultRobotsAgent, "Bot whose robots directives apply.")
+	flag.StringVar(&robotsAgent, "--agent", scanner.DefaultRobotsAgent, "Bot whose robots directives apply.")
+	flag.BoolVar(&respectNofollow, "n", false, "Don't follow links on pages marked nofollow.")
+	flag.BoolVar(&respectNofollow, "--nofollow", false, "Don't follow links on pages marked nofollow.")
+	flag.BoolVar(&nearDuplicates, "d", false, "Group near identical titles, descriptions and h1s.")
+	flag.BoolVar(&nearDuplicates, "--near", false, "Group near identical titles, descriptions and h1s.")
+	flag.StringVar(&graphFile, "g", "", "File to export the link graph to, as DOT or GraphML.")
+	flag.StringVar(&graphFile, "--graph", "", "File to export the link graph to, as DOT or GraphML.")
+	flag.BoolVar(&graphPagesOnly, "p", false, "Export only internal html pages.")
+	flag.BoolVar(&graphPagesOnly, "--graph-pages", false, "Export only internal html pages.")
+	flag.BoolVar(&graphByDir, "D", false, "Collapse exported URLs into their directories.")
+	flag.BoolVar(&graphByDir, "--graph-dirs", false, "Collapse exported URLs into their directories.")
+	flag.BoolVar(&graphColor, "C", false, "Colour exported nodes by status.")
+	flag.BoolVar(&graphColor, "--graph-color", false, "Colour exported nodes by status.")
 	flag.BoolVar(&showVersion, "V", false, "Show version")
 	flag.BoolVar(&showVersion, "--version", false, "Show version")
 	flag.Usage = scanner.PrintUsageAndExit
@@ -36,5 +83,53 @@ func main() {
 
 	runtime.GOMAXPROCS(procs)
 	s := scanner.New(hostname, maxRunMin, maxWorkers)
+	s.ReportFile = reportFile
+	s.CheckpointFile = checkpointFile
+	s.CheckpointSec = checkpointSec
+	limits, err := scanner.ParseSizeLimits(maxBodySize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	s.MaxBodySize = limits
+	s.TimeoutSec = timeoutSec
+	s.RobotsAgent = robotsAgent
+	s.RespectNofollow = respectNofollow
+	s.NearDuplicates = nearDuplicates
+	s.GraphFile = graphFile
+	s.GraphPagesOnly = graphPagesOnly
+	s.GraphByDir = graphByDir
+	s.GraphColor = graphColor
+	if storeFile != "" {
+		st, err := scanner.OpenDiskStore(storeFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to open store: %s\n", err)
+			os.Exit(1)
+		}
+		s.Store = st
+	}
+	if historyFile != "" {
+		h, err := scanner.OpenHistory(historyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to open history: %s\n", err)
+			os.Exit(1)
+		}
+		s.History = h
+	}
+	if resume {
+		if err := s.Resume(); err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to resume scan: %s\n", err)
+			os.Exit(1)
+		}
+	}
 	s.Run()
+	s.Store.Close()
+	if s.History != nil {
+		s.History.Close()
+	}
+
+	// Interrupted scans exit with a distinct code.
+	if s.StopReason == scanner.StopSignal {
+		os.Exit(scanner.ExitInterrupted)
+	}
 }
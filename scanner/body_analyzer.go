@@ -2,6 +2,8 @@ package scanner
 
 import (
 	"net/url"
+	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/net/html"
 )
@@ -16,7 +18,15 @@ const (
 // bodyAnalyzer is used to analyze a body of html text returned from a scan.
 // Updates job statistics and finds addional URLs that need scanning.
 type bodyAnalyzer struct {
-	ScanJob *scanJob
+	ScanJob     *scanJob
+	robotsAgent string             // The bot whose meta robots elements apply as well as name="robots".
+	heading     *Heading           // The heading whose text is being read.
+	scopes      []*structuredScope // The microdata and RDFa items whose properties are being read.
+	depth       int                // How many elements deep the tokenizer is.
+	media       string             // The URL type of source elements in the current video or audio.
+	anchors     map[string]bool    // The anchors recorded so far.
+	link        *Link              // The link whose text is being read.
+	linkLabel   bool               // Is the link named by an aria-label instead of its text?
 }
 
 // bodyAnalyzerNew regurns a new instance of a bodyAnalyzer
@@ -27,28 +37,69 @@ func bodyAnalyzerNew(j *scanJob) *bodyAnalyzer {
 // analyzeBody parses a page and analyzes it for SEO purposes, placing the results in stats.
 func (a *bodyAnalyzer) analyzeBody() {
 	p := html.NewTokenizer(a.ScanJob.Body)
+	a.heading = nil
+	a.scopes = nil
+	a.depth = 0
+	a.media = ""
+	a.anchors = make(map[string]bool)
+	a.link = nil
 	for {
 		tt := p.Next()
 		switch tt {
 		case html.ErrorToken:
+			a.finishHeadings()
+			a.finishLinks()
+			a.finishStructured()
 			return
-		case html.StartTagToken:
+		case html.TextToken:
+			text := string(p.Text())
+			if a.heading != nil {
+				a.heading.Text += text
+			}
+			a.linkText(text)
+		case html.EndTagToken:
+			a.endTag(p)
+		case html.StartTagToken, html.SelfClosingTagToken:
 			tk := p.Token()
+			a.structuredTag(tk, tt == html.SelfClosingTagToken)
+			a.resourceFound(tk)
+			for _, attr := range tk.Attr {
+				switch {
+				case attr.Key == "style":
+					a.inlineStyle(attr.Val)
+				case attr.Key == "id", attr.Key == "name" && tk.DataAtom.String() == "a":
+					a.anchorName(attr.Val)
+				}
+			}
+			if level := headingLevel(tk.DataAtom.String()); level > 0 {
+				a.headingFound(level)
+				continue
+			}
 			switch tk.DataAtom.String() {
 			case "a":
 				a.anchorFound(tk)
+				a.linkFound(tk)
 			case "link":
 				a.canonicalFound(tk)
 			case "meta":
 				a.metaDescriptions(tk)
 			case "title":
 				a.checkTitle(p)
+			case "style":
+				if css, ok := a.elementText(p); ok {
+					a.styleElement(css)
+				}
 			case "img":
 				a.checkImages(tk)
-			case "h1":
-				a.checkH1()
+				a.headingImage(tk)
+				a.linkImage(tk)
 			case "script":
 				a.checkJS(tk)
+				for _, attr := range tk.Attr {
+					if attr.Key == "type" && strings.EqualFold(attr.Val, "application/ld+json") {
+						a.structuredJSON(p)
+					}
+				}
 			default:
 			}
 		default: // NOP
@@ -56,12 +107,39 @@ func (a *bodyAnalyzer) analyzeBody() {
 	}
 }
 
+// endTag closes the element of the end tag just read.
+func (a *bodyAnalyzer) endTag(p *html.Tokenizer) {
+	name, _ := p.TagName()
+	if headingLevel(string(name)) > 0 {
+		a.heading = nil
+	}
+	if string(name) == urlVideo || string(name) == urlAudio {
+		a.media = ""
+	}
+	if string(name) == "a" {
+		a.link = nil
+	}
+	a.structuredEnd()
+}
+
+// elementText reads the text of an element that holds only text ex: title, style or
+// script. An empty element has none, and its end tag is closed here as it has been read.
+func (a *bodyAnalyzer) elementText(p *html.Tokenizer) (string, bool) {
+	switch p.Next() {
+	case html.TextToken:
+		return string(p.Text()), true
+	case html.EndTagToken:
+		a.endTag(p)
+	}
+	return "", false
+}
+
 // anchorFound will scan an anchor element for new URLs
 func (a *bodyAnalyzer) anchorFound(tk html.Token) {
 	for _, attr := range tk.Attr {
 		if attr.Key == "href" {
 			u, err := url.Parse(attr.Val)
-			if err == nil {
+			if err == nil && a.fragmentLink(u) {
 				a.ScanJob.Children = append(a.ScanJob.Children, &scanJobChild{
 					URL:     u,
 					URLType: "html",
@@ -75,20 +153,33 @@ func (a *bodyAnalyzer) anchorFound(tk html.Token) {
 // or if a CSS file was identified, record it as a new scan job.
 func (a *bodyAnalyzer) canonicalFound(tk html.Token) {
 	var csFound bool
+	var canonicalFound bool
+	var alternateFound bool
 	var href string
+	var hreflang string
 	for _, attr := range tk.Attr {
 		switch attr.Key {
 		case "rel":
 			switch attr.Val {
 			case "canonical":
-				a.ScanJob.Stat.Canonical = true // Canonical found
+				canonicalFound = true
 			case "stylesheet":
 				csFound = true
+			case "alternate":
+				alternateFound = true
 			}
 		case "href":
 			href = attr.Val
+		case "hreflang":
+			hreflang = attr.Val
 		}
 	}
+	if canonicalFound {
+		a.canonical(href)
+	}
+	if alternateFound && hreflang != "" {
+		addHreflang(a.ScanJob, hreflang, href)
+	}
 	// Store any CSS found as a new job
 	if csFound && href != "" {
 		u, err := url.Parse(href)
@@ -101,25 +192,70 @@ func (a *bodyAnalyzer) canonicalFound(tk html.Token) {
 	}
 }
 
-// metaDescriptions will scan a meta element for description information and set stats
+// canonical records the target of a canonical link and scans it so it can be validated.
+func (a *bodyAnalyzer) canonical(href string) {
+	st := a.ScanJob.Stat
+	if st.Canonical {
+		st.addIssue(RuleCanonicalMultiple)
+	}
+	st.Canonical = true // Canonical found
+	u, err := url.Parse(href)
+	if err != nil || href == "" {
+		return
+	}
+	if !u.IsAbs() {
+		st.addIssue(RuleCanonicalRelative)
+	}
+	page := st.pageURL()
+	c, ok := resolveCanonical(page, href)
+	if !ok || st.CanonicalURL != "" {
+		return
+	}
+	st.CanonicalURL = c
+	if c != page.String() {
+		cu, _ := url.Parse(c)
+		a.ScanJob.Children = append(a.ScanJob.Children, &scanJobChild{
+			URL:     cu,
+			URLType: "html",
+		})
+	}
+}
+
+// metaDescriptions will scan a meta element for description, robots and social media
+// information and set stats
 func (a *bodyAnalyzer) metaDescriptions(tk html.Token) {
 	var descFound bool
+	var name string
+	var property string
 	var content string
 
 	for _, attr := range tk.Attr {
 		switch attr.Key {
 		case "name":
+			name = attr.Val
 			if attr.Val == "description" {
 				descFound = true
 			}
+		case "property":
+			property = attr.Val
 		case "content":
 			content = attr.Val
 		}
 	}
+	a.ScanJob.robots.addMeta(name, content, a.robotsAgent)
+	// Twitter Card tags are often written with name= instead of property=.
+	if property == "" {
+		property = name
+	}
+	a.socialFound(property, content)
 
 	if descFound {
+		if a.ScanJob.Stat.MetaCount == 0 {
+			a.ScanJob.Stat.Description = content
+		}
 		a.ScanJob.Stat.MetaCount++
-		if len(content) < metaDescriptionMin || len(content) > metaDescriptionMax {
+		n := utf8.RuneCountInString(content)
+		if n < metaDescriptionMin || n > metaDescriptionMax {
 			a.ScanJob.Stat.MetaSizedErr = true
 		}
 	}
@@ -127,17 +263,15 @@ func (a *bodyAnalyzer) metaDescriptions(tk html.Token) {
 
 // checkTitle will scan a title element for content and set stats.
 func (a *bodyAnalyzer) checkTitle(p *html.Tokenizer) {
-	var title string
-
 	// Try and get the text
-	tt := p.Next()
-	if tt == html.TextToken {
-		tk := p.Token()
-		title = tk.String()
-	}
+	title, _ := a.elementText(p)
 
+	// Sizes are in characters, not bytes.
+	if a.ScanJob.Stat.TitleCount == 0 {
+		a.ScanJob.Stat.Title = title
+	}
 	a.ScanJob.Stat.TitleCount++
-	if len(title) < titleMin || len(title) > titleMax {
+	if n := utf8.RuneCountInString(title); n < titleMin || n > titleMax {
 		a.ScanJob.Stat.TitleSizedErr = true
 	}
 }
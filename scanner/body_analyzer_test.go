@@ -58,6 +58,8 @@ var (
 			"Invalid attr1 value should have flagged a count error."},
 		{"meta", `name="description"`, "Kontent", strings.Repeat("*", metaDescriptionMin), 1, true,
 			"Invalid attr2 should have flagged a count error."},
+		{"meta", `name="description"`, "content", strings.Repeat("ü", metaDescriptionMax), 1, false,
+			"Multibyte description should be sized in characters."},
 	}
 
 	testBodyTitle = []struct {
@@ -73,6 +75,8 @@ var (
 		{"Title", "", 1, true, "Invalid missing text should have been found."},
 		{"Title", strings.Repeat("*", titleMax+1), 1, true, "Invalid high size should have been found."},
 		{"Xitle", strings.Repeat("*", titleMin), 0, false, "Missing title should not have triggered err."},
+		{"Title", strings.Repeat("é", titleMax), 1, false, "Multibyte title should be sized in characters."},
+		{"Title", strings.Repeat("&amp;", titleMin), 1, false, "Entities should be sized as one character."},
 	}
 
 	testBodyImg = []struct {
@@ -218,3 +222,22 @@ func TestBodyJS(t *testing.T) {
 	t.Parallel()
 	t.Skipf("Covered by TestScanRun")
 }
+
+func TestBodyAnalyzerText(t *testing.T) {
+	t.Parallel()
+	s := `<title>Red Shoes</title><title>Other</title>` +
+		`<meta name="description" content="All about shoes."><meta name="description" content="Other">` +
+		`<h1>Red <a href="/shoes">Shoes</a>
+		</h1><h1>Other</h1>`
+	j := scanJobNew(testURLRoot, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(s))
+	a := bodyAnalyzerNew(j)
+	a.analyzeBody()
+	st := a.ScanJob.Stat
+	if st.Title != "Red Shoes" || st.Description != "All about shoes." || st.H1 != "Red Shoes" {
+		t.Errorf("Invalid text. Title: %q Description: %q H1: %q", st.Title, st.Description, st.H1)
+	}
+	if len(a.ScanJob.Children) != 1 {
+		t.Errorf("Links inside an h1 should have been found.")
+	}
+}
@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	sizeLimitAll = "*" // The URL type whose limit applies to types without their own.
+)
+
+var (
+	errBodyTooLarge = errors.New("Body is too large.") // Returned when a body passes its size limit.
+
+	// Size suffixes and their multipliers.
+	sizeUnits = []struct {
+		suffix string
+		size   int64
+	}{
+		{"G", 1 << 30},
+		{"M", 1 << 20},
+		{"K", 1 << 10},
+	}
+)
+
+// SizeLimits are the maximum number of body bytes read for each URL type ex: html, img.
+// URL types without a limit use the * limit, or are read in full if there is none.
+type SizeLimits map[string]int64
+
+// ParseSizeLimits parses size limits ex: "html=10M,img=512K". Sizes are in bytes, or in
+// kilobytes, megabytes or gigabytes with a K, M or G suffix. A size of 0 is no limit.
+func ParseSizeLimits(s string) (SizeLimits, error) {
+	l := make(SizeLimits)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Invalid size limit %q.", f)
+		}
+		v := strings.ToUpper(strings.TrimSpace(kv[1]))
+		mult := int64(1)
+		for _, u := range sizeUnits {
+			if strings.HasSuffix(v, u.suffix) {
+				v = strings.TrimSuffix(v, u.suffix)
+				mult = u.size
+				break
+			}
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Invalid size limit %q.", f)
+		}
+		l[strings.TrimSpace(kv[0])] = n * mult
+	}
+	return l, nil
+}
+
+// limit returns the limit for a URL type. Links to pages use the html limit, images
+// found in srcset, poster and icon references use the img limit, and any other type
+// without a limit uses the * limit.
+func (l SizeLimits) limit(ut string) int64 {
+	if isPageType(ut) {
+		ut = "html"
+	}
+	if n, ok := l[ut]; ok {
+		return n
+	}
+	if n, ok := l[urlServedTypes[ut]]; ok {
+		return n
+	}
+	return l[sizeLimitAll]
+}
+
+// String returns the limits in the format read by ParseSizeLimits, ordered by URL type.
+func (l SizeLimits) String() string {
+	types := make([]string, 0, len(l))
+	for t := range l {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	fields := make([]string, 0, len(types))
+	for _, t := range types {
+		v := strconv.FormatInt(l[t], 10)
+		for _, u := range sizeUnits {
+			if l[t] != 0 && l[t]%u.size == 0 {
+				v = strconv.FormatInt(l[t]/u.size, 10) + u.suffix
+				break
+			}
+		}
+		fields = append(fields, t+"="+v)
+	}
+	return strings.Join(fields, ",")
+}
+
+// limitedBody counts the bytes read from a body and stops reading once it passes a limit.
+type limitedBody struct {
+	body  io.ReadCloser // The body being read.
+	stat  *Stats        // Stats of the job the body belongs to.
+	limit int64         // The maximum number of bytes, 0 for no limit.
+}
+
+// limitBody returns a body that records how many bytes are read from it in the stats
+// and fails with errBodyTooLarge, flagging the job, once more than limit bytes are read.
+// Bodies cut short by the request timeout are flagged too.
+func limitBody(j *scanJob, body io.ReadCloser, limit int64) io.ReadCloser {
+	return &limitedBody{body: body, stat: j.Stat, limit: limit}
+}
+
+// Read reads from the body. One byte past the limit is read to tell a body of exactly
+// the limit from one that is too large.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.limit > 0 {
+		if b.stat.Transferred > b.limit {
+			return 0, errBodyTooLarge
+		}
+		if max := b.limit + 1 - b.stat.Transferred; int64(len(p)) > max {
+			p = p[:max]
+		}
+	}
+	n, err := b.body.Read(p)
+	b.stat.Transferred += int64(n)
+	if b.limit > 0 && b.stat.Transferred > b.limit {
+		b.stat.addIssue(RuleBodySize)
+		return n, errBodyTooLarge
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		b.stat.addIssue(RuleTimeout)
+	}
+	return n, err
+}
+
+// Close closes the body. Bodies that are too large are not read to the end, so the
+// connection is not reused.
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
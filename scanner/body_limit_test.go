@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestParseSizeLimits(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		limits      string
+		expected    string
+		expectedErr bool
+	}{
+		{DefaultMaxBodySize, DefaultMaxBodySize, false},
+		{"html=1024, img=2g", "html=1K,img=2G", false},
+		{"js=1000,css=0", "css=0,js=1000", false},
+		{"html=5k", "html=5K", false},
+		{"", "", false},
+		{"html", "", true},
+		{"=5M", "", true},
+		{"html=lots", "", true},
+		{"html=-1", "", true},
+	}
+	for _, tc := range tests {
+		l, err := ParseSizeLimits(tc.limits)
+		if (err != nil) != tc.expectedErr {
+			t.Errorf("Unexpected error for %q: %v", tc.limits, err)
+			continue
+		}
+		if err == nil && l.String() != tc.expected {
+			t.Errorf("Invalid limits for %q. Expected %q, received %q.", tc.limits, tc.expected, l)
+		}
+	}
+}
+
+func TestSizeLimitsLimit(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		limits   string
+		urlType  string
+		expected int64
+	}{
+		{"html=10M,img=20M,srcset=1M", "html", 10 << 20},
+		{"html=10M,img=20M,srcset=1M", "refresh", 10 << 20},
+		{"html=10M,img=20M,srcset=1M", "poster", 20 << 20},
+		{"html=10M,img=20M,srcset=1M", "srcset", 1 << 20},
+		{"html=10M,img=20M,srcset=1M", "js", 0},
+		{"html=10M,img=20M,srcset=1M", "form", 0},
+		{"*=5M,html=10M,video=0", "font", 5 << 20},
+		{"*=5M,html=10M,video=0", "area", 10 << 20},
+		{"*=5M,html=10M,video=0", "video", 0},
+		{"*=5M", "html", 5 << 20},
+		{DefaultMaxBodySize, "audio", 20 << 20},
+	}
+	for _, tc := range tests {
+		l, _ := ParseSizeLimits(tc.limits)
+		if n := l.limit(tc.urlType); n != tc.expected {
+			t.Errorf("Invalid limit for %s in %q. Expected %d, received %d.", tc.urlType, tc.limits, tc.expected, n)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+const (
+	summaryMaxCanonical = 100 // The number of pages that canonicalize elsewhere listed.
+)
+
+// CanonicalPage is a page that names another URL as its canonical version.
+type CanonicalPage struct {
+	URL       string   `json:"url"`       // The page.
+	Canonical string   `json:"canonical"` // The canonical URL it names.
+	Problems  []string `json:"problems"`  // Rules the canonical target breaks.
+}
+
+// resolveCanonical returns the absolute form of a canonical href found on a page.
+func resolveCanonical(page *url.URL, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	u = page.ResolveReference(u)
+	u.Fragment = ""
+	return u.String(), true
+}
+
+// checkCanonical records the canonical URL from a Link header and flags canonicals that
+// disagree with each other or point to another host.
+func checkCanonical(j *scanJob, h http.Header) {
+	for _, l := range linkHeader(h["Link"]) {
+		if !l.rels("canonical") {
+			continue
+		}
+		if c, ok := resolveCanonical(j.Stat.pageURL(), l.URL); ok {
+			j.Stat.CanonicalHeader = c
+			j.Stat.Canonical = true
+		}
+		break
+	}
+	st := j.Stat
+	if st.CanonicalURL != "" && st.CanonicalHeader != "" && st.CanonicalURL != st.CanonicalHeader {
+		st.addIssue(RuleCanonicalMismatch)
+	}
+	c := st.CanonicalURL
+	if c == "" {
+		c = st.CanonicalHeader
+	}
+	if u, err := url.Parse(c); err == nil && c != "" && u.Host != st.pageURL().Host {
+		st.addIssue(RuleCanonicalHost)
+	}
+}
+
+// canonicalOf returns the canonical URL of a result, from the page or the Link header.
+func canonicalOf(st *Stats) string {
+	if st.CanonicalURL != "" {
+		return st.CanonicalURL
+	}
+	return st.CanonicalHeader
+}
+
+// canonicalProblems returns the rules the canonical target of a page breaks. Targets
+// that were not scanned can't be checked.
+func (s *Scanner) canonicalProblems(canonical string) []string {
+	p := []string{}
+	results, err := s.Store.Results(canonical)
+	if err != nil {
+		s.log.Errorf("Unable to read results for %s: %s", canonical, err)
+		return p
+	}
+	parents := make([]string, 0, len(results))
+	for k := range results {
+		parents = append(parents, k)
+	}
+	if len(parents) == 0 {
+		return p
+	}
+	sort.Strings(parents)
+	target := results[parents[0]]
+	if target.StatusCode != http.StatusOK {
+		p = append(p, RuleCanonicalStatus)
+	}
+	if target.RedirectURL != "" {
+		p = append(p, RuleCanonicalRedirect)
+	}
+	if target.NoIndex {
+		p = append(p, RuleCanonicalNoIndex)
+	}
+	if c := canonicalOf(target); c != "" && c != canonical {
+		p = append(p, RuleCanonicalChain)
+	}
+	return p
+}
+
+// canonicalSort orders pages that canonicalize elsewhere with problems first, then by URL.
+type canonicalSort []*CanonicalPage
+
+func (s canonicalSort) Len() int      { return len(s) }
+func (s canonicalSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s canonicalSort) Less(i, j int) bool {
+	if (len(s[i].Problems) > 0) != (len(s[j].Problems) > 0) {
+		return len(s[i].Problems) > 0
+	}
+	return s[i].URL < s[j].URL
+}
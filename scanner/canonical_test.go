@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalAnalyze(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		body        string
+		header      string
+		expectedURL string
+		expectedIss []string
+		message     string
+	}{
+		{`<link rel="canonical" href="http://www.example.com/a#top">`, "", "http://www.example.com/a", []string{},
+			"Absolute canonical should be recorded without a fragment."},
+		{`<link rel="canonical" href="/a">`, "", "http://www.example.com/a", []string{RuleCanonicalRelative},
+			"Relative canonical should be resolved and flagged."},
+		{`<link rel="canonical" href="http://www.example.com/a"><link rel="canonical" href="http://www.example.com/b">`,
+			"", "http://www.example.com/a", []string{RuleCanonicalMultiple}, "Multiple canonicals should be flagged."},
+		{`<link rel="canonical" href="http://other.com/a">`, "", "http://other.com/a", []string{RuleCanonicalHost},
+			"Canonical on another host should be flagged."},
+		{`<link rel="canonical" href="http://www.example.com/a">`, `<http://www.example.com/b>; rel="canonical"`,
+			"http://www.example.com/a", []string{RuleCanonicalMismatch}, "Header mismatch should be flagged."},
+		{`<link rel="canonical" href="http://www.example.com/a">`, `</a>; rel="canonical"`,
+			"http://www.example.com/a", []string{}, "Matching header should not be flagged."},
+	}
+	for _, tc := range tests {
+		u, _ := url.Parse("http://www.example.com/page")
+		j := scanJobNew(u, "html", nil)
+		j.Body = ioutil.NopCloser(bytes.NewBufferString(tc.body))
+		bodyAnalyzerNew(j).analyzeBody()
+		h := http.Header{}
+		if tc.header != "" {
+			h.Set("Link", tc.header)
+		}
+		checkCanonical(j, h)
+		if j.Stat.CanonicalURL != tc.expectedURL || !reflect.DeepEqual(j.Stat.Issues, tc.expectedIss) {
+			t.Errorf("%s Canonical: %s Issues: %v", tc.message, j.Stat.CanonicalURL, j.Stat.Issues)
+		}
+	}
+}
+
+func TestCanonicalRedirected(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/old")
+	j := scanJobNew(u, "html", nil)
+	j.Stat.RedirectURL = "http://www2.example.com/new"
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(`<link rel="canonical" href="/new">`))
+	bodyAnalyzerNew(j).analyzeBody()
+	checkCanonical(j, http.Header{})
+	if j.Stat.CanonicalURL != "http://www2.example.com/new" {
+		t.Errorf("Canonical should be resolved against the redirect target: %s", j.Stat.CanonicalURL)
+	}
+	if len(j.Children) != 0 || !reflect.DeepEqual(j.Stat.Issues, []string{RuleCanonicalRelative}) {
+		t.Errorf("Canonical of the redirect target should be a self reference. Children: %v Issues: %v",
+			j.Children, j.Stat.Issues)
+	}
+}
+
+func TestCanonicalHeaderOnly(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/doc.pdf")
+	j := scanJobNew(u, "html", nil)
+	h := http.Header{}
+	h.Set("Link", `<http://www.example.com/doc>; rel="canonical"`)
+	checkCanonical(j, h)
+	if !j.Stat.Canonical || j.Stat.CanonicalHeader != "http://www.example.com/doc" {
+		t.Errorf("Canonical from the Link header should have been recorded.")
+	}
+}
+
+func TestSummarizeCanonical(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	put := func(rawurl string, status int, canonical string, redirect string, noindex bool) {
+		u, _ := url.Parse(rawurl)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = status
+		st.Canonical = canonical != ""
+		st.CanonicalURL = canonical
+		st.RedirectURL = redirect
+		st.NoIndex = noindex
+		s.Store.PutResult(st)
+	}
+	put("http://example.com/a", 200, "http://example.com/a", "", false)
+	put("http://example.com/b", 200, "http://example.com/a", "", false)
+	put("http://example.com/c", 200, "http://example.com/d", "", false)
+	put("http://example.com/d", 200, "http://example.com/e", "", true)
+	put("http://example.com/e", 301, "", "http://example.com/f", false)
+	put("http://example.com/g", 200, "http://example.com/missing", "", false)
+
+	sum := s.Summarize()
+	if sum.Canonicalized != 4 {
+		t.Errorf("Invalid canonicalized count: %d", sum.Canonicalized)
+	}
+	problems := map[string]string{}
+	for _, c := range sum.Canonical {
+		problems[c.URL] = strings.Join(c.Problems, ",")
+	}
+	expected := map[string]string{
+		"http://example.com/b": "",
+		"http://example.com/c": RuleCanonicalNoIndex + "," + RuleCanonicalChain,
+		"http://example.com/d": RuleCanonicalStatus + "," + RuleCanonicalRedirect,
+		"http://example.com/g": "",
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("Invalid canonical problems: %v", problems)
+	}
+	if sum.Canonical[0].URL != "http://example.com/c" || sum.Canonical[3].URL != "http://example.com/g" {
+		t.Errorf("Pages with problems should be listed first.")
+	}
+	if sum.Violations[RuleCanonicalChain] != 1 {
+		t.Errorf("Canonical problems should be counted as violations: %v", sum.Violations)
+	}
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/transform"
+)
+
+const (
+	charsetPeekLen = 1024 // How many bytes of a page are examined for a charset declaration.
+)
+
+var (
+	// Byte order marks and the charset they declare.
+	charsetBOMs = []struct {
+		bom  []byte
+		name string
+	}{
+		{[]byte{0xef, 0xbb, 0xbf}, "utf-8"},
+		{[]byte{0xfe, 0xff}, "utf-16be"},
+		{[]byte{0xff, 0xfe}, "utf-16le"},
+	}
+)
+
+// charsetName returns the canonical name of a charset label ex: "ISO-8859-1" =>
+// "windows-1252". Unknown labels are returned in lower case.
+func charsetName(label string) string {
+	if _, name := charset.Lookup(label); name != "" {
+		return name
+	}
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// bomCharset returns the charset declared by a byte order mark at the start of a page,
+// and the length of the mark.
+func bomCharset(b []byte) (string, int) {
+	for _, c := range charsetBOMs {
+		if bytes.HasPrefix(b, c.bom) {
+			return c.name, len(c.bom)
+		}
+	}
+	return "", 0
+}
+
+// headerCharset returns the charset declared in a Content-Type ex: "text/html; charset=utf-8".
+func headerCharset(ct string) string {
+	_, params, err := mime.ParseMediaType(ct)
+	if err != nil || params["charset"] == "" {
+		return ""
+	}
+	return charsetName(params["charset"])
+}
+
+// metaCharset returns the charset declared by a <meta charset> or
+// <meta http-equiv="Content-Type"> element at the start of a page.
+func metaCharset(b []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(b))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tk := z.Token()
+			switch tk.DataAtom.String() {
+			case "body":
+				return ""
+			case "meta":
+				var httpEquiv bool
+				var content string
+				for _, attr := range tk.Attr {
+					switch attr.Key {
+					case "charset":
+						return charsetName(attr.Val)
+					case "http-equiv":
+						httpEquiv = strings.EqualFold(attr.Val, "content-type")
+					case "content":
+						content = attr.Val
+					}
+				}
+				if httpEquiv {
+					if cs := headerCharset(content); cs != "" {
+						return cs
+					}
+				}
+			}
+		}
+	}
+}
+
+// decode detects the character encoding of a page from its byte order mark, Content-Type
+// header and meta declaration, records it in the stats, and returns the body decoded to
+// UTF-8. A byte order mark wins over the header, and the header over the meta element.
+// Pages that declare no charset, or declare charsets that disagree, are flagged. When
+// nothing is declared the encoding is guessed from the content.
+func decode(j *scanJob, contentType string, body io.ReadCloser) io.ReadCloser {
+	br := bufio.NewReaderSize(body, charsetPeekLen)
+	peek, _ := br.Peek(charsetPeekLen)
+
+	bom, bomLen := bomCharset(peek)
+	var name string
+	for _, cs := range []string{bom, headerCharset(contentType), metaCharset(peek)} {
+		switch {
+		case cs == "":
+		case name == "":
+			name = cs
+		case cs != name:
+			j.Stat.addIssue(RuleCharsetConflict)
+		}
+	}
+	if name == "" {
+		j.Stat.addIssue(RuleCharsetMissing)
+	}
+
+	e, _ := charset.Lookup(name)
+	if e == nil {
+		e, name, _ = charset.DetermineEncoding(peek, "")
+	}
+	j.Stat.Charset = name
+	// The byte order mark isn't part of the text, and the tokenizer would read it as such.
+	br.Discard(bomLen)
+	if name == "utf-8" {
+		return sniffedBody{br, body}
+	}
+	return sniffedBody{transform.NewReader(br, e.NewDecoder()), body}
+}
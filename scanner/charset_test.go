@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+var (
+	testCharsets = []struct {
+		contentType string
+		body        string
+		expected    string
+		expectedIss string
+		expectedTxt string
+		message     string
+	}{
+		{"text/html; charset=utf-8", "<p>caf\xc3\xa9</p>", "utf-8", "", "café",
+			"UTF-8 header should be used."},
+		{"text/html; charset=ISO-8859-1", "<p>caf\xe9</p>", "windows-1252", "", "café",
+			"Latin-1 header should be decoded."},
+		{"text/html", `<meta charset="windows-1252"><p>caf` + "\xe9</p>", "windows-1252", "", "café",
+			"Meta charset should be used."},
+		{"text/html", `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1">` +
+			"<p>caf\xe9</p>", "windows-1252", "", "café", "Meta http-equiv should be used."},
+		{"text/html; charset=iso-8859-1", "\xef\xbb\xbf<p>caf\xc3\xa9</p>", "utf-8", RuleCharsetConflict, "café",
+			"Byte order mark should win over the header."},
+		{"text/html", "\xfe\xff\x00<\x00p\x00>\x00c\x00a\x00f\x00\xe9", "utf-16be", "", "<p>café",
+			"UTF-16 byte order mark should be used."},
+		{"text/html; charset=utf-8", `<meta charset="iso-8859-1"><p>café</p>`, "utf-8", RuleCharsetConflict,
+			"café", "Header should win over the meta element."},
+		{"text/html", "<p>cafe</p>", "windows-1252", RuleCharsetMissing, "cafe",
+			"Missing declaration should have been flagged."},
+		{"text/html; charset=bogus", "<p>cafe</p>", "windows-1252", "", "cafe",
+			"Unknown charset should fall back to detection."},
+	}
+)
+
+func TestCharsetDecode(t *testing.T) {
+	t.Parallel()
+	for _, tc := range testCharsets {
+		j := scanJobNew(testURLRoot, "html", nil)
+		b, err := ioutil.ReadAll(decode(j, tc.contentType, ioutil.NopCloser(bytes.NewBufferString(tc.body))))
+		if err != nil {
+			t.Errorf("%s Error: %s", tc.message, err)
+			continue
+		}
+		if j.Stat.Charset != tc.expected {
+			t.Errorf("%s Expected %q, received %q.", tc.message, tc.expected, j.Stat.Charset)
+		}
+		if !strings.Contains(string(b), tc.expectedTxt) || bytes.HasPrefix(b, []byte("\ufeff")) {
+			t.Errorf("%s Body not decoded: %q", tc.message, b)
+		}
+		iss := strings.Join(j.Stat.Issues, ",")
+		if iss != tc.expectedIss {
+			t.Errorf("%s Expected issues %q, received %q.", tc.message, tc.expectedIss, iss)
+		}
+	}
+}
+
+func TestCharsetName(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		label    string
+		expected string
+	}{
+		{"UTF-8", "utf-8"},
+		{"utf8", "utf-8"},
+		{"latin1", "windows-1252"},
+		{"Shift_JIS", "shift_jis"},
+		{"Bogus", "bogus"},
+	}
+	for _, tc := range tests {
+		if n := charsetName(tc.label); n != tc.expected {
+			t.Errorf("Invalid name for %s. Expected %s, received %s.", tc.label, tc.expected, n)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package scanner
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	checkpointVersion = 1 // The format version of the checkpoint file.
+)
+
+// checkpoint is the saved state of a scan that can be resumed.
+type checkpoint struct {
+	Version    int          `json:"version"`    // The format version of the file.
+	ConfigHash string       `json:"configHash"` // Hash of the configuration that affects the crawl.
+	SavedTime  time.Time    `json:"savedTime"`  // When the checkpoint was saved.
+	Requests   int          `json:"requests"`   // The number of URL scans completed.
+	Results    []*Stats     `json:"results"`    // URL test results, unless the store is persistent.
+	Pending    []*storedJob `json:"pending"`    // Jobs with no result yet.
+}
+
+// configHash returns a hash of the configuration that would make a saved crawl
+// incompatible if changed.
+func (s *Scanner) configHash() string {
+	h := sha1.New()
+	io.WriteString(h, fmt.Sprintf("root=%s\n", s.RootURL))
+	io.WriteString(h, fmt.Sprintf("persistent=%t\n", s.Store.Persistent()))
+	io.WriteString(h, fmt.Sprintf("maxBodySize=%s\n", s.MaxBodySize))
+	io.WriteString(h, fmt.Sprintf("robotsAgent=%s\n", s.RobotsAgent))
+	io.WriteString(h, fmt.Sprintf("respectNofollow=%t\n", s.RespectNofollow))
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// checkpointNew is a factory for creating a checkpoint of the current state of the scan.
+// The jobs sent to the workers, the frontier and results are only saved if the store
+// does not survive a restart by itself. A store that does keeps its jobs in progress.
+func (s *Scanner) checkpointNew() (*checkpoint, error) {
+	c := &checkpoint{
+		Version:    checkpointVersion,
+		ConfigHash: s.configHash(),
+		SavedTime:  time.Now(),
+		Requests:   s.Requests,
+		Results:    []*Stats{},
+		Pending:    []*storedJob{},
+	}
+	if s.Store.Persistent() {
+		return c, nil
+	}
+	for _, j := range s.pendingJobs() {
+		c.Pending = append(c.Pending, storedJobNew(j))
+	}
+	err := s.Store.EachResult(func(u string, parents map[string]*Stats) error {
+		for _, stat := range parents {
+			c.Results = append(c.Results, stat)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = s.Store.EachJob(func(j *scanJob) error {
+		c.Pending = append(c.Pending, storedJobNew(j))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// writeCheckpoint writes the state of the scan to a file. The file is replaced in one
+// step so a crash while writing never leaves a damaged checkpoint behind.
+func (s *Scanner) writeCheckpoint(path string) error {
+	c, err := s.checkpointNew()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(c); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// saveCheckpoint writes the checkpoint file and logs any failure.
+func (s *Scanner) saveCheckpoint() {
+	if err := s.writeCheckpoint(s.CheckpointFile); err != nil {
+		s.log.Errorf("Unable to write checkpoint %s: %s", s.CheckpointFile, err)
+	}
+}
+
+// Resume loads the state of a previous scan from the checkpoint file so Run continues
+// the crawl. Resuming is refused if the configuration changed incompatibly.
+func (s *Scanner) Resume() error {
+	if s.CheckpointFile == "" {
+		return errors.New("A checkpoint file is required to resume.")
+	}
+	f, err := os.Open(s.CheckpointFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var c checkpoint
+	if err := json.NewDecoder(f).Decode(&c); err != nil {
+		return fmt.Errorf("Invalid checkpoint file: %s", err)
+	}
+	if c.Version != checkpointVersion {
+		return fmt.Errorf("Checkpoint version %d is not supported.", c.Version)
+	}
+	if c.ConfigHash != s.configHash() {
+		return errors.New("Configuration has changed since the checkpoint was saved.")
+	}
+
+	// A store that survives a restart already holds the frontier, the jobs in progress
+	// and results.
+	if !s.Store.Persistent() {
+		if err := s.Store.Reset(); err != nil {
+			return err
+		}
+	}
+	s.Requests = c.Requests
+	for _, stat := range c.Results {
+		if err := s.Store.PutResult(stat); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.Pending {
+		if err := s.Store.PushJob(p.job()); err != nil {
+			return err
+		}
+	}
+	s.resumed = true
+	return nil
+}
@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckpointConfigHash(t *testing.T) {
+	t.Parallel()
+	a := New("example.com", testMaxRunMin, testMaxWorkers)
+	b := New("example.com", testMaxRunMin+1, testMaxWorkers+1)
+	c := New("example2.com", testMaxRunMin, testMaxWorkers)
+	if a.configHash() != b.configHash() {
+		t.Errorf("Run limits should not change the configuration hash.")
+	}
+	if a.configHash() == c.configHash() {
+		t.Errorf("Hostname should change the configuration hash.")
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.CheckpointFile = path
+	s.Requests = 2
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com/a", "html", "http://example.com", 200, time.Millisecond)
+	u, _ := url.Parse("http://example.com/b")
+	s.pending[scanJobNew(u, "html", s.RootURL)] = true
+	v, _ := url.Parse("http://example.com/c")
+	s.Store.PushJob(scanJobNew(v, "img", s.RootURL))
+	if err := s.writeCheckpoint(path); err != nil {
+		t.Fatalf("Unable to write checkpoint: %s", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("Temporary checkpoint file should have been removed.")
+	}
+
+	r := New("example.com", testMaxRunMin, testMaxWorkers)
+	r.CheckpointFile = path
+	if err := r.Resume(); err != nil {
+		t.Fatalf("Unable to resume: %s", err)
+	}
+	if !r.resumed || r.Requests != 2 || r.Store.ResultLen() != 2 {
+		t.Errorf("Results were not resumed.")
+	}
+	if !r.scanned("http://example.com/a", "http://example.com") {
+		t.Errorf("Result was not resumed with its parent.")
+	}
+	if r.Store.JobLen() != 2 {
+		t.Errorf("Pending jobs were not resumed.")
+	}
+	j, _ := r.Store.PopJob()
+	if j == nil || j.Stat.URL.String() != "http://example.com/b" ||
+		j.Stat.ParentURL.String() != "http://example.com" {
+		t.Errorf("Jobs in progress should have been resumed first.")
+	}
+
+	x := New("example2.com", testMaxRunMin, testMaxWorkers)
+	x.CheckpointFile = path
+	if err := x.Resume(); err == nil {
+		t.Errorf("Resume should have been refused for a different configuration.")
+	}
+	m := New("example.com", testMaxRunMin, testMaxWorkers)
+	m.CheckpointFile = filepath.Join(dir, "missing.json")
+	if err := m.Resume(); err == nil {
+		t.Errorf("Resume should have failed for a missing file.")
+	}
+	n := New("example.com", testMaxRunMin, testMaxWorkers)
+	if err := n.Resume(); err == nil {
+		t.Errorf("Resume should have failed without a checkpoint file.")
+	}
+}
+
+func TestCheckpointStopDrained(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "checkpoint.json")
+
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.CheckpointFile = path
+	j := scanJobNew(s.RootURL, "html", s.RootURL)
+	j.Stat.StatusCode = 200
+	c, _ := url.Parse("/a")
+	j.Children = append(j.Children, &scanJobChild{URL: c, URLType: "html"})
+	s.pending[j] = true
+	s.doneCh <- j
+	s.Stop()
+	if s.QueuedCount != 1 || len(s.Queued) != 1 || s.Queued[0] != "http://example.com/a" {
+		t.Errorf("Links found on drained results should be queued: %d %v", s.QueuedCount, s.Queued)
+	}
+
+	r := New("example.com", testMaxRunMin, testMaxWorkers)
+	r.CheckpointFile = path
+	if err := r.Resume(); err != nil {
+		t.Fatalf("Unable to resume: %s", err)
+	}
+	if r.Store.ResultLen() != 1 || r.Store.JobLen() != 1 {
+		t.Errorf("Links found on drained results should be resumed. Results: %d Jobs: %d",
+			r.Store.ResultLen(), r.Store.JobLen())
+	}
+}
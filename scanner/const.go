@@ -1,9 +1,15 @@
 package scanner
 
 const (
-	version           = "0.1.1-alpha"
-	DefaultHostname   = "example.com"
-	DefaultMaxProcs   = 1
-	DefaultMaxMin     = 5
-	DefaultMaxWorkers = 4
+	version              = "0.1.1-alpha"
+	DefaultHostname      = "example.com"
+	DefaultMaxProcs      = 1
+	DefaultMaxMin        = 5
+	DefaultMaxWorkers    = 4
+	DefaultCheckpointSec = 60
+	DefaultMaxBodySize   = "*=20M,css=5M,html=10M,img=20M,js=5M"
+	DefaultTimeoutSec    = 60
+	DefaultRobotsAgent   = "googlebot"
+
+	ExitInterrupted = 130 // Exit code when a scan is stopped by a signal.
 )
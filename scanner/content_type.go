@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	sniffLen     = 512     // How many bytes of a body are used to sniff its Content-Type.
+	servedOther  = "other" // Served type of anything that isn't a page or known asset.
+	servedHTML   = "html"
+	servedImage  = "img"
+	servedCSS    = "css"
+	servedScript = "js"
+	servedFont   = "font"
+	servedVideo  = "video"
+	servedAudio  = "audio"
+)
+
+// servedType returns the type of url for a Content-Type ex: "text/css; charset=utf-8" => css.
+func servedType(ct string) string {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return servedOther
+	}
+	switch {
+	case mt == "text/html", mt == "application/xhtml+xml":
+		return servedHTML
+	case strings.HasPrefix(mt, "image/"):
+		return servedImage
+	case mt == "text/css":
+		return servedCSS
+	case mt == "application/javascript", mt == "text/javascript", mt == "application/x-javascript",
+		mt == "application/ecmascript", mt == "text/ecmascript":
+		return servedScript
+	case strings.HasPrefix(mt, "video/"):
+		return servedVideo
+	case strings.HasPrefix(mt, "audio/"):
+		return servedAudio
+	case strings.HasPrefix(mt, "font/"), strings.HasPrefix(mt, "application/font-"),
+		strings.HasPrefix(mt, "application/x-font-"), mt == "application/vnd.ms-fontobject":
+		return servedFont
+	}
+	return servedOther
+}
+
+// sniffedBody is a body that had its start read to sniff the Content-Type.
+type sniffedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// classify records the type of url actually served. When the server sends no
+// Content-Type, the start of the body is sniffed instead, and the returned body must be
+// read in place of the response body. Assets that are served as a different type than
+// they were referenced as are flagged.
+func classify(j *scanJob, resp *http.Response) io.ReadCloser {
+	var body io.ReadCloser = resp.Body
+	ct := resp.Header.Get("Content-Type")
+	if ct == "" && resp.Request != nil && resp.Request.Method == "GET" {
+		br := bufio.NewReaderSize(resp.Body, sniffLen)
+		peek, _ := br.Peek(sniffLen)
+		ct = http.DetectContentType(peek)
+		body = sniffedBody{br, resp.Body}
+	}
+	if ct == "" {
+		return body
+	}
+	j.Stat.ServedType = servedType(ct)
+	if want, ok := urlServedTypes[j.Stat.URLType]; ok && j.Stat.ServedType != want &&
+		resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		j.Stat.addIssue(RuleTypeMismatch)
+	}
+	return body
+}
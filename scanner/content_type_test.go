@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+var (
+	testContentTypes = []struct {
+		contentType string
+		expected    string
+	}{
+		{"text/html; charset=utf-8", "html"},
+		{"application/xhtml+xml", "html"},
+		{"image/png", "img"},
+		{"text/css", "css"},
+		{"application/javascript", "js"},
+		{"text/javascript; charset=utf-8", "js"},
+		{"font/woff2", "font"},
+		{"application/font-woff", "font"},
+		{"video/mp4", "video"},
+		{"audio/mpeg", "audio"},
+		{"application/pdf", "other"},
+		{"not a type", "other"},
+	}
+
+	testContentClassify = []struct {
+		path             string
+		urlType          string
+		expectedServed   string
+		expectedMismatch bool
+		expectedH1       int
+		message          string
+	}{
+		{"/page", "html", "html", false, 1, "Pages served as html should be analyzed."},
+		{"/sniff", "html", "html", false, 1, "Pages without a Content-Type should be sniffed."},
+		{"/report.pdf", "html", "other", false, 0, "Links to documents should not be analyzed."},
+		{"/style.css", "css", "html", true, 0, "Stylesheet served as html should be flagged."},
+		{"/image.png", "img", "img", false, 0, "Image served as an image should not be flagged."},
+		{"/missing.css", "css", "html", false, 0, "Error pages should not be flagged."},
+		{"/image.png", "icon", "img", false, 0, "Icon served as an image should not be flagged."},
+		{"/page", "poster", "html", true, 0, "Poster served as html should be flagged."},
+		{"/page", "iframe", "html", false, 0, "Frames can be served as anything."},
+		{"/page", "area", "html", false, 1, "Image map links should be analyzed as pages."},
+	}
+)
+
+// testContentHandler serves URLs with a variety of Content-Types.
+func testContentHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/page", "/style.css":
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, "<html><h1>Page</h1></html>")
+	case "/sniff":
+		w.Header()["Content-Type"] = nil
+		io.WriteString(w, "<html><h1>Page</h1></html>")
+	case "/report.pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		io.WriteString(w, "%PDF-1.4 <h1>not a page</h1>")
+	case "/image.png":
+		w.Header().Set("Content-Type", "image/png")
+	default:
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestContentServedType(t *testing.T) {
+	t.Parallel()
+	for _, tc := range testContentTypes {
+		if st := servedType(tc.contentType); st != tc.expected {
+			t.Errorf("Invalid served type for %s. Expected: %s Received: %s", tc.contentType,
+				tc.expected, st)
+		}
+	}
+}
+
+func TestContentClassify(t *testing.T) {
+	t.Parallel()
+	srvr := httptest.NewServer(http.HandlerFunc(testContentHandler))
+	defer srvr.Close()
+
+	var wg sync.WaitGroup
+	jobq := make(chan *scanJob, len(testContentClassify))
+	doneCh := make(chan *scanJob, len(testContentClassify))
+	wg.Add(1)
+	go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{})
+	for _, tc := range testContentClassify {
+		u, _ := url.Parse(srvr.URL + tc.path)
+		jobq <- scanJobNew(u, tc.urlType, nil)
+		j := <-doneCh
+		mismatch := j.Stat.hasIssue(RuleTypeMismatch)
+		if j.Stat.ServedType != tc.expectedServed || mismatch != tc.expectedMismatch ||
+			j.Stat.H1Count != tc.expectedH1 {
+			t.Errorf("%s Received: %s %v %d", tc.message, j.Stat.ServedType, j.Stat.Issues,
+				j.Stat.H1Count)
+		}
+	}
+	close(jobq)
+	wg.Wait()
+}
+
+func TestContentSniffedBody(t *testing.T) {
+	t.Parallel()
+	srvr := httptest.NewServer(http.HandlerFunc(testContentHandler))
+	defer srvr.Close()
+	u, _ := url.Parse(srvr.URL + "/sniff")
+	j := scanJobNew(u, "html", nil)
+	resp, err := fetch(context.Background(), &http.Client{}, j)
+	if err != nil {
+		t.Fatalf("Unable to fetch: %s", err)
+	}
+	body := classify(j, resp)
+	b, _ := ioutil.ReadAll(body)
+	body.Close()
+	if string(b) != "<html><h1>Page</h1></html>" {
+		t.Errorf("Sniffed body should still be read in full: %s", b)
+	}
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"io"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	cssCommentRe = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssURLRe     = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)\s]*))\s*\)`)
+	cssImportRe  = regexp.MustCompile(`(?i)@import\s+(?:url\(\s*)?(?:"([^"]*)"|'([^']*)'|([^)\s;]+))`)
+
+	// Font file extensions.
+	fontExtensions = map[string]bool{
+		".woff":  true,
+		".woff2": true,
+		".ttf":   true,
+		".otf":   true,
+		".eot":   true,
+	}
+)
+
+// cssMatch returns the URL captured by one of the quoted or unquoted groups of a match.
+func cssMatch(m []string) string {
+	for _, g := range m[1:] {
+		if g != "" {
+			return strings.TrimSpace(g)
+		}
+	}
+	return ""
+}
+
+// cssReferences returns the URLs referenced by url() and @import in a stylesheet,
+// resolved against the URL of the stylesheet. Imports are css, font files are fonts and
+// anything else is treated as an image. Inline data: URLs are skipped.
+func cssReferences(base *url.URL, css string) []*scanJobChild {
+	css = cssCommentRe.ReplaceAllString(css, "")
+	imports := make(map[string]bool)
+	refs := []string{}
+	for _, m := range cssImportRe.FindAllStringSubmatch(css, -1) {
+		if u := cssMatch(m); u != "" {
+			imports[u] = true
+			refs = append(refs, u)
+		}
+	}
+	for _, m := range cssURLRe.FindAllStringSubmatch(css, -1) {
+		if u := cssMatch(m); u != "" && !imports[u] {
+			refs = append(refs, u)
+		}
+	}
+
+	children := []*scanJobChild{}
+	for _, ref := range refs {
+		if strings.HasPrefix(strings.ToLower(ref), "data:") {
+			continue
+		}
+		u, err := url.Parse(ref)
+		if err != nil {
+			continue
+		}
+		u = base.ResolveReference(u)
+		ut := "img"
+		switch {
+		case imports[ref]:
+			ut = "css"
+		case fontExtensions[strings.ToLower(path.Ext(u.Path))]:
+			ut = servedFont
+		}
+		children = append(children, &scanJobChild{URL: u, URLType: ut})
+	}
+	return children
+}
+
+// analyzeCSS reads a stylesheet and records the assets it references as children of the
+// job.
+func analyzeCSS(j *scanJob, body io.Reader) {
+	b, _ := ioutil.ReadAll(body) // Whatever was read before an error is still analyzed.
+	j.Children = append(j.Children, cssReferences(j.Stat.pageURL(), string(b))...)
+}
+
+// styleElement records the assets imported or referenced by a style element.
+func (a *bodyAnalyzer) styleElement(css string) {
+	a.ScanJob.Children = append(a.ScanJob.Children, cssReferences(a.ScanJob.Stat.pageURL(), css)...)
+}
+
+// inlineStyle records the assets referenced by a style attribute. Attributes can't import
+// stylesheets, so only those with a url() are parsed.
+func (a *bodyAnalyzer) inlineStyle(style string) {
+	if strings.Contains(strings.ToLower(style), "url(") {
+		a.ScanJob.Children = append(a.ScanJob.Children, cssReferences(a.ScanJob.Stat.pageURL(), style)...)
+	}
+}
@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCSSReferences(t *testing.T) {
+	t.Parallel()
+	base, _ := url.Parse("http://example.com/css/site.css")
+	tests := []struct {
+		css      string
+		expected []string
+		message  string
+	}{
+		{`body { background: url(../img/bg.png) }`, []string{"img http://example.com/img/bg.png"},
+			"Relative images should resolve against the stylesheet."},
+		{`a { background: URL( "a b.gif" ) } b { background: url('/c.jpg') }`,
+			[]string{"img http://example.com/css/a%20b.gif", "img http://example.com/c.jpg"},
+			"Quoted URLs should have been found."},
+		{`@import "print.css"; @import url(theme.css) screen;`,
+			[]string{"css http://example.com/css/print.css", "css http://example.com/css/theme.css"},
+			"Imports should be stylesheets."},
+		{`@font-face { src: url(f.woff2) format("woff2"), url(f.TTF) }`,
+			[]string{"font http://example.com/css/f.woff2", "font http://example.com/css/f.TTF"},
+			"Font files should be fonts."},
+		{`a { background: url(data:image/png;base64,AAA) } /* url(old.png) */`, []string{},
+			"Data URLs and comments should be skipped."},
+	}
+	for _, tc := range tests {
+		received := []string{}
+		for _, c := range cssReferences(base, tc.css) {
+			received = append(received, c.URLType+" "+c.URL.String())
+		}
+		if !reflect.DeepEqual(received, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, received)
+		}
+	}
+}
+
+func TestBodyAnalyzerStyle(t *testing.T) {
+	t.Parallel()
+	s := `<style>@import "/a.css"; h1 { background: url(/h1.png) }</style>` +
+		`<div style="background-image: url('/div.png')">text</div><style>@import "b.css";</style>`
+	j := scanJobNew(testURLRoot, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(s))
+	a := bodyAnalyzerNew(j)
+	a.analyzeBody()
+	received := []string{}
+	for _, c := range a.ScanJob.Children {
+		received = append(received, c.URLType+" "+c.URL.String())
+	}
+	expected := []string{"css http://example.com/a.css", "img http://example.com/h1.png",
+		"img http://example.com/div.png", "css http://example.com/b.css"}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Inline styles should have been analyzed. Expected: %v Received: %v", expected, received)
+	}
+}
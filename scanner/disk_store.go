@@ -0,0 +1,323 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	diskStoreSep = "\x00" // Separates the URL from the parent in result keys.
+)
+
+var (
+	diskStoreJobs     = []byte("jobs")     // Bucket of the frontier keyed by sequence.
+	diskStoreInflight = []byte("inflight") // Bucket of jobs popped but without a result.
+	diskStoreResults  = []byte("results")  // Bucket of results keyed by url + sep + parent.
+
+	diskStoreTimeout = time.Second // How long to wait for the lock on the store file.
+)
+
+// diskStore is a Store that keeps the frontier and results in an embedded database file
+// so that memory stays bounded no matter how large the site is.
+type diskStore struct {
+	db      *bolt.DB // The database.
+	jobLen  int      // Count of jobs in the frontier.
+	urlLen  int      // Count of URLs with results.
+	jobHead uint64   // Sequence of the next job to pop.
+}
+
+// OpenDiskStore opens or creates a Store in a database file.
+func OpenDiskStore(path string) (Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: diskStoreTimeout})
+	if err != nil {
+		return nil, err
+	}
+	d := &diskStore{db: db}
+	if err := d.init(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// init creates the buckets and loads the counts of an existing file. Jobs that were in
+// progress when the file was last closed are added back to the frontier.
+func (d *diskStore) init() error {
+	return d.db.Update(func(tx *bolt.Tx) error {
+		jb, err := tx.CreateBucketIfNotExists(diskStoreJobs)
+		if err != nil {
+			return err
+		}
+		ib, err := tx.CreateBucketIfNotExists(diskStoreInflight)
+		if err != nil {
+			return err
+		}
+		rb, err := tx.CreateBucketIfNotExists(diskStoreResults)
+		if err != nil {
+			return err
+		}
+		d.jobLen = jb.Stats().KeyN
+		c := ib.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.First() {
+			if err := diskStorePush(jb, v); err != nil {
+				return err
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			d.jobLen++
+		}
+		d.urlLen = 0
+		d.jobHead = 0
+		if k, _ := jb.Cursor().First(); k != nil {
+			d.jobHead = binary.BigEndian.Uint64(k)
+		}
+		var last []byte
+		return rb.ForEach(func(k, v []byte) error {
+			u := diskStoreURL(k)
+			if !bytes.Equal(u, last) {
+				d.urlLen++
+				last = append(last[:0], u...)
+			}
+			return nil
+		})
+	})
+}
+
+// diskStoreKey returns the result key of a URL and parent.
+func diskStoreKey(u string, p string) []byte {
+	return []byte(u + diskStoreSep + p)
+}
+
+// diskStoreURL returns the URL part of a result key.
+func diskStoreURL(k []byte) []byte {
+	if i := bytes.Index(k, []byte(diskStoreSep)); i >= 0 {
+		return k[:i]
+	}
+	return k
+}
+
+// diskStorePush adds a stored job to the end of the frontier bucket.
+func diskStorePush(b *bolt.Bucket, v []byte) error {
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, seq)
+	return b.Put(k, v)
+}
+
+// PushJob adds a job to the end of the frontier.
+func (d *diskStore) PushJob(j *scanJob) error {
+	return d.PutPage(nil, []*scanJob{j})
+}
+
+// PopJob removes the first job from the frontier. The job is kept as in progress until
+// its result is stored, so it is scanned again if the scan stops before then.
+func (d *diskStore) PopJob() (*scanJob, error) {
+	var sj *storedJob
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(diskStoreJobs)
+		k := make([]byte, 8)
+		binary.BigEndian.PutUint64(k, d.jobHead)
+		k, v := b.Cursor().Seek(k)
+		if k == nil {
+			return nil
+		}
+		sj = &storedJob{}
+		if err := json.Unmarshal(v, sj); err != nil {
+			return err
+		}
+		d.jobHead = binary.BigEndian.Uint64(k) + 1
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+		// A job whose result is already stored won't store another.
+		rk := diskStoreKey(sj.URL.String(), sj.ParentURL.String())
+		if tx.Bucket(diskStoreResults).Get(rk) != nil {
+			return nil
+		}
+		return tx.Bucket(diskStoreInflight).Put(rk, v)
+	})
+	if err != nil || sj == nil {
+		return nil, err
+	}
+	d.jobLen--
+	return sj.job(), nil
+}
+
+// EachJob visits the jobs in the frontier in order.
+func (d *diskStore) EachJob(fn func(j *scanJob) error) error {
+	return d.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(diskStoreJobs).ForEach(func(k, v []byte) error {
+			sj := &storedJob{}
+			if err := json.Unmarshal(v, sj); err != nil {
+				return err
+			}
+			return fn(sj.job())
+		})
+	})
+}
+
+// JobLen returns the number of jobs in the frontier.
+func (d *diskStore) JobLen() int {
+	return d.jobLen
+}
+
+// PutResult stores a result under its URL and parent.
+func (d *diskStore) PutResult(st *Stats) error {
+	return d.PutPage(st, nil)
+}
+
+// PutPage stores a result, if not nil, and adds the jobs found with it to the end of the
+// frontier in a single transaction, so a page costs one write to the file.
+func (d *diskStore) PutPage(st *Stats, jobs []*scanJob) error {
+	var v []byte
+	if st != nil {
+		var err error
+		if v, err = json.Marshal(st); err != nil {
+			return err
+		}
+	}
+	js := make([][]byte, 0, len(jobs))
+	for _, j := range jobs {
+		b, err := json.Marshal(storedJobNew(j))
+		if err != nil {
+			return err
+		}
+		js = append(js, b)
+	}
+	var newURL bool
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		if st != nil {
+			u := st.URL.String()
+			rk := diskStoreKey(u, st.ParentURL.String())
+			b := tx.Bucket(diskStoreResults)
+			prefix := []byte(u + diskStoreSep)
+			k, _ := b.Cursor().Seek(prefix)
+			newURL = k == nil || !bytes.HasPrefix(k, prefix)
+			if err := b.Put(rk, v); err != nil {
+				return err
+			}
+			if err := tx.Bucket(diskStoreInflight).Delete(rk); err != nil {
+				return err
+			}
+		}
+		b := tx.Bucket(diskStoreJobs)
+		for _, j := range js {
+			if err := diskStorePush(b, j); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		d.jobLen += len(jobs)
+		if newURL {
+			d.urlLen++
+		}
+	}
+	return err
+}
+
+// Result returns the result of a URL found on a parent.
+func (d *diskStore) Result(u string, p string) (*Stats, error) {
+	var st *Stats
+	err := d.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(diskStoreResults).Get(diskStoreKey(u, p))
+		if v == nil {
+			return nil
+		}
+		st = &Stats{}
+		return json.Unmarshal(v, st)
+	})
+	return st, err
+}
+
+// Results returns the results of a URL for every parent.
+func (d *diskStore) Results(u string) (map[string]*Stats, error) {
+	parents := make(map[string]*Stats)
+	err := d.db.View(func(tx *bolt.Tx) error {
+		prefix := []byte(u + diskStoreSep)
+		c := tx.Bucket(diskStoreResults).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			st := &Stats{}
+			if err := json.Unmarshal(v, st); err != nil {
+				return err
+			}
+			parents[string(k[len(prefix):])] = st
+		}
+		return nil
+	})
+	return parents, err
+}
+
+// EachResult visits the results in URL order.
+func (d *diskStore) EachResult(fn func(u string, parents map[string]*Stats) error) error {
+	return d.db.View(func(tx *bolt.Tx) error {
+		var last string
+		parents := make(map[string]*Stats)
+		c := tx.Bucket(diskStoreResults).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			u := string(diskStoreURL(k))
+			if u != last && len(parents) > 0 {
+				if err := fn(last, parents); err != nil {
+					return err
+				}
+				parents = make(map[string]*Stats)
+			}
+			last = u
+			st := &Stats{}
+			if err := json.Unmarshal(v, st); err != nil {
+				return err
+			}
+			parents[string(k[len(u)+len(diskStoreSep):])] = st
+		}
+		if len(parents) > 0 {
+			return fn(last, parents)
+		}
+		return nil
+	})
+}
+
+// ResultLen returns the number of URLs with results.
+func (d *diskStore) ResultLen() int {
+	return d.urlLen
+}
+
+// Persistent returns true. The frontier and results survive a restart.
+func (d *diskStore) Persistent() bool {
+	return true
+}
+
+// Reset removes all jobs and results.
+func (d *diskStore) Reset() error {
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{diskStoreJobs, diskStoreInflight, diskStoreResults} {
+			if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return d.init()
+}
+
+// Close closes the database file.
+func (d *diskStore) Close() error {
+	if d.db == nil {
+		return errors.New("Store is already closed.")
+	}
+	err := d.db.Close()
+	d.db = nil
+	return err
+}
@@ -0,0 +1,106 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskStore(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "store.db")
+
+	st, err := OpenDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unable to open store: %s", err)
+	}
+	if !st.Persistent() {
+		t.Errorf("Disk store should be persistent.")
+	}
+	testStore(t, st)
+
+	// Contents survive reopening.
+	root, _ := url.Parse("http://example.com")
+	a, _ := url.Parse("http://example.com/a")
+	b, _ := url.Parse("http://example.com/b")
+	st.PushJob(scanJobNew(a, "html", root))
+	st.PushJob(scanJobNew(b, "html", root))
+	st.PushJob(scanJobNew(root, "html", root))
+	st.PopJob()
+	st.PopJob()
+	st.PutPage(StatsNew(b, "html", root), []*scanJob{scanJobNew(b, "img", b)})
+	st.PutResult(StatsNew(root, "html", root))
+	if err := st.Close(); err != nil {
+		t.Errorf("Unable to close store: %s", err)
+	}
+	if err := st.Close(); err == nil {
+		t.Errorf("Closing twice should have failed.")
+	}
+	st, err = OpenDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unable to reopen store: %s", err)
+	}
+	defer st.Close()
+	if st.JobLen() != 3 || st.ResultLen() != 2 {
+		t.Errorf("Store contents should have survived reopening.")
+	}
+	expected := []string{root.String(), b.String(), a.String()}
+	for _, e := range expected {
+		if j, _ := st.PopJob(); j == nil || j.Stat.URL.String() != e {
+			t.Errorf("Frontier should have resumed at the next job, then the jobs in progress.")
+		}
+	}
+}
+
+func TestDiskStoreResume(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "store.db")
+	st, err := OpenDiskStore(path)
+	if err != nil {
+		t.Fatalf("Unable to open store: %s", err)
+	}
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.Store = st
+	s.CheckpointFile = filepath.Join(dir, "checkpoint.json")
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, 0)
+	a, _ := url.Parse("http://example.com/a")
+	st.PushJob(scanJobNew(a, "html", s.RootURL))
+	s.dispatch()
+	if err := s.writeCheckpoint(s.CheckpointFile); err != nil {
+		t.Fatalf("Unable to write checkpoint: %s", err)
+	}
+	st.Close()
+	if st, err = OpenDiskStore(path); err != nil {
+		t.Fatalf("Unable to reopen store: %s", err)
+	}
+	defer st.Close()
+
+	m := New("example.com", testMaxRunMin, testMaxWorkers)
+	m.CheckpointFile = s.CheckpointFile
+	if err := m.Resume(); err == nil {
+		t.Errorf("Resume into a memory store should have been refused.")
+	}
+
+	r := New("example.com", testMaxRunMin, testMaxWorkers)
+	r.Store = st
+	r.CheckpointFile = s.CheckpointFile
+	if err := r.Resume(); err != nil {
+		t.Fatalf("Unable to resume: %s", err)
+	}
+	if st.ResultLen() != 1 || st.JobLen() != 1 {
+		t.Errorf("Store should have kept results and requeued jobs in progress.")
+	}
+}
@@ -0,0 +1,114 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	summaryMaxDuplicates    = 20 // The number of duplicate clusters reported for each field.
+	summaryMaxDuplicateURLs = 10 // The number of URLs listed for each duplicate cluster.
+)
+
+// DuplicateCluster is a group of pages that share the same text.
+type DuplicateCluster struct {
+	Text  string   `json:"text"`  // The shared text, as found on the first page.
+	Count int      `json:"count"` // How many pages share the text.
+	URLs  []string `json:"urls"`  // The first pages that share the text.
+}
+
+// duplicateField is a page field checked for duplicates.
+type duplicateField struct {
+	rule     string                       // The violation reported for each duplicate page.
+	text     func(st *Stats) string       // Gets the text of the field.
+	clusters map[string]*DuplicateCluster // Clusters of pages by normalized text.
+}
+
+// duplicateKey returns the text used to compare pages. Exact comparisons only ignore
+// leading, trailing and repeated spaces. Near comparisons also ignore case, punctuation
+// and numbers so boilerplate such as "Product 12 | Shop" and "product 13 - shop" match.
+func duplicateKey(text string, near bool) string {
+	if !near {
+		return strings.Join(strings.Fields(text), " ")
+	}
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}), " ")
+}
+
+// findDuplicates groups the pages that share a title, meta description or h1 and adds
+// the clusters to the summary. Only pages that loaded and may be indexed are compared.
+func (s *Scanner) findDuplicates(sum *Summary) {
+	fields := []*duplicateField{
+		{rule: RuleTitleDuplicate, text: func(st *Stats) string { return st.Title }},
+		{rule: RuleMetaDuplicate, text: func(st *Stats) string { return st.Description }},
+		{rule: RuleH1Duplicate, text: func(st *Stats) string { return st.H1 }},
+	}
+	for _, f := range fields {
+		f.clusters = make(map[string]*DuplicateCluster)
+	}
+	err := s.Store.EachResult(func(u string, parents map[string]*Stats) error {
+		var stat *Stats
+		for _, st := range parents {
+			stat = st
+			break
+		}
+		if stat == nil || !stat.isPage() || stat.StatusCode != 200 || stat.NoIndex {
+			return nil
+		}
+		for _, f := range fields {
+			text := f.text(stat)
+			key := duplicateKey(text, s.NearDuplicates)
+			if key == "" {
+				continue
+			}
+			c := f.clusters[key]
+			if c == nil {
+				c = &DuplicateCluster{Text: text, URLs: []string{}}
+				f.clusters[key] = c
+			}
+			c.Count++
+			if len(c.URLs) < summaryMaxDuplicateURLs {
+				c.URLs = append(c.URLs, u)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Errorf("Unable to read results: %s", err)
+	}
+
+	sum.DuplicateTitles = duplicateClusters(fields[0], sum)
+	sum.DuplicateDescriptions = duplicateClusters(fields[1], sum)
+	sum.DuplicateH1s = duplicateClusters(fields[2], sum)
+}
+
+// duplicateClusters counts the duplicate pages of a field as violations and returns the
+// largest clusters.
+func duplicateClusters(f *duplicateField, sum *Summary) []*DuplicateCluster {
+	dups := []*DuplicateCluster{}
+	for _, c := range f.clusters {
+		if c.Count > 1 {
+			sum.Violations[f.rule] += c.Count
+			dups = append(dups, c)
+		}
+	}
+	sort.Sort(duplicateSort(dups))
+	if len(dups) > summaryMaxDuplicates {
+		dups = dups[:summaryMaxDuplicates]
+	}
+	return dups
+}
+
+// duplicateSort orders duplicate clusters by descending size, then by text.
+type duplicateSort []*DuplicateCluster
+
+func (s duplicateSort) Len() int      { return len(s) }
+func (s duplicateSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s duplicateSort) Less(i, j int) bool {
+	if s[i].Count != s[j].Count {
+		return s[i].Count > s[j].Count
+	}
+	return s[i].Text < s[j].Text
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestDuplicateKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		text     string
+		near     bool
+		expected string
+	}{
+		{"  Red  Shoes ", false, "Red Shoes"},
+		{"Red Shoes | Shop", false, "Red Shoes | Shop"},
+		{"Product 12 | Shop", true, "product shop"},
+		{"product 13 - SHOP!", true, "product shop"},
+		{"Café déjà vu", true, "café déjà vu"},
+		{"123", true, ""},
+	}
+	for _, tc := range tests {
+		if k := duplicateKey(tc.text, tc.near); k != tc.expected {
+			t.Errorf("Invalid key for %q. Expected %q, received %q.", tc.text, tc.expected, k)
+		}
+	}
+}
+
+func TestSummarizeDuplicates(t *testing.T) {
+	t.Parallel()
+	put := func(s *Scanner, path string, title string, desc string, h1 string, noindex bool) {
+		u, _ := url.Parse("http://example.com" + path)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = 200
+		st.Title = title
+		st.Description = desc
+		st.H1 = h1
+		st.NoIndex = noindex
+		s.Store.PutResult(st)
+	}
+	tests := []struct {
+		near          bool
+		expectedCount int
+		expectedTitle int
+		expectedDesc  int
+		expectedH1    int
+	}{
+		{false, 1, 2, 3, 0},
+		{true, 2, 4, 3, 2},
+	}
+	for _, tc := range tests {
+		s := New("example.com", testMaxRunMin, testMaxWorkers)
+		s.NearDuplicates = tc.near
+		put(s, "/1", "Product 1 | Shop", "Buy now.", "Product 1", false)
+		put(s, "/2", "Product 2 | Shop", "Buy now.", "Product 2", false)
+		put(s, "/3", "Shop", "Buy now.", "", false)
+		put(s, "/4", "Shop", "", "", false)
+		put(s, "/5", "Shop", "Buy now.", "", true)
+		sum := s.Summarize()
+		if len(sum.DuplicateTitles) != tc.expectedCount || sum.Violations[RuleTitleDuplicate] != tc.expectedTitle {
+			t.Errorf("Invalid duplicate titles (near %t): %v", tc.near, sum.Violations)
+		}
+		if sum.Violations[RuleMetaDuplicate] != tc.expectedDesc || sum.Violations[RuleH1Duplicate] != tc.expectedH1 {
+			t.Errorf("Invalid duplicate descriptions or h1s (near %t): %v", tc.near, sum.Violations)
+		}
+	}
+}
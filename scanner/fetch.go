@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// fetch requests the URL of a job. Pages and stylesheets are downloaded with GET, so they
+// can be analyzed. Other assets are checked with HEAD first to save bandwidth. Servers
+// that reject HEAD, or misreport it with an error status, are asked again with a GET for
+// the first byte only, and finally with a full GET if the range can't be satisfied. The
+// method used is recorded in the stats.
+func fetch(ctx context.Context, cl *http.Client, j *scanJob) (*http.Response, error) {
+	if isPageType(j.Stat.URLType) || j.Stat.URLType == "css" {
+		return request(ctx, cl, j, "GET", "")
+	}
+	resp, err := request(ctx, cl, j, "HEAD", "")
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+	drain(resp.Body)
+
+	resp, err = request(ctx, cl, j, "GET", "bytes=0-0")
+	if err != nil {
+		return resp, err
+	}
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// The range was honoured: the asset exists, so report it as we would a full GET.
+		resp.StatusCode = http.StatusOK
+		resp.ContentLength = rangeTotal(resp.Header.Get("Content-Range"))
+		return resp, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		drain(resp.Body)
+		return request(ctx, cl, j, "GET", "")
+	}
+	return resp, nil
+}
+
+// request sends a single request for the URL of a job, optionally for a byte range.
+func request(ctx context.Context, cl *http.Client, j *scanJob, method string, rng string) (*http.Response, error) {
+	req, err := http.NewRequest(method, j.Stat.URL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if rng != "" {
+		req.Header.Set("Range", rng)
+	}
+	conditionalHeaders(req, j.Prev)
+	j.Stat.Method = method
+	ctx, j.trace = traceContext(ctx)
+	return cl.Do(req.WithContext(ctx))
+}
+
+// rangeTotal returns the complete length from a Content-Range header
+// ex: "bytes 0-0/1234" => 1234, or -1 if unknown.
+func rangeTotal(cr string) int64 {
+	i := strings.LastIndex(cr, "/")
+	if i < 0 {
+		return -1
+	}
+	n, err := strconv.ParseInt(cr[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// drain reads what is left of a body and closes it so the connection can be reused.
+func drain(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
+}
+
+// conditionalHeaders asks the server to only return the URL if it changed since the
+// previous run.
+func conditionalHeaders(req *http.Request, prev *historyEntry) {
+	if prev == nil || prev.Stat == nil {
+		return
+	}
+	if prev.Stat.ETag != "" {
+		req.Header.Set("If-None-Match", prev.Stat.ETag)
+	}
+	if prev.Stat.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.Stat.LastModified)
+	}
+}
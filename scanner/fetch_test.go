@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testFetchMethods = []struct {
+		path           string
+		urlType        string
+		expectedStatus int
+		expectedMethod string
+		expectedLength int64
+		message        string
+	}{
+		{"/page", "html", http.StatusOK, "GET", 4, "Pages should be downloaded with GET."},
+		{"/head", "img", http.StatusOK, "HEAD", 4, "Assets should be checked with HEAD."},
+		{"/page", "css", http.StatusOK, "GET", 4, "Stylesheets should be downloaded with GET."},
+		{"/nohead", "img", http.StatusOK, "GET", 4, "Rejected HEAD should fall back to a ranged GET."},
+		{"/norange", "js", http.StatusOK, "GET", 4, "Ignored range should be accepted as a full GET."},
+		{"/empty", "img", http.StatusOK, "GET", 0, "Unsatisfiable range should fall back to a full GET."},
+		{"/missing", "img", http.StatusNotFound, "GET", 0, "Missing assets should be reported."},
+	}
+)
+
+// testFetchHandler serves assets with a variety of HEAD and range support.
+func testFetchHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/page", "/head":
+		w.Header().Set("Content-Length", "4")
+		io.WriteString(w, "body")
+	case "/nohead":
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.ServeContent(w, r, "a.png", time.Time{}, strings.NewReader("body"))
+	case "/norange":
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusNotImplemented)
+			return
+		}
+		w.Header().Set("Content-Length", "4")
+		io.WriteString(w, "body")
+	case "/empty":
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "" {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.Header().Set("Content-Length", "0")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	t.Parallel()
+	srvr := httptest.NewServer(http.HandlerFunc(testFetchHandler))
+	defer srvr.Close()
+	cl := &http.Client{}
+	for _, tc := range testFetchMethods {
+		u, _ := url.Parse(srvr.URL + tc.path)
+		j := scanJobNew(u, tc.urlType, nil)
+		resp, err := fetch(context.Background(), cl, j)
+		if err != nil {
+			t.Errorf("Unable to fetch %s: %s", tc.path, err)
+			continue
+		}
+		drain(resp.Body)
+		if resp.StatusCode != tc.expectedStatus || j.Stat.Method != tc.expectedMethod ||
+			resp.ContentLength != tc.expectedLength {
+			t.Errorf("%s Received: %d %s %d", tc.message, resp.StatusCode, j.Stat.Method,
+				resp.ContentLength)
+		}
+	}
+}
+
+func TestFetchRangeTotal(t *testing.T) {
+	t.Parallel()
+	if n := rangeTotal("bytes 0-0/1234"); n != 1234 {
+		t.Errorf("Invalid range total: %d", n)
+	}
+	if n := rangeTotal("bytes 0-0/*"); n != -1 {
+		t.Errorf("Unknown range total should be -1: %d", n)
+	}
+	if n := rangeTotal(""); n != -1 {
+		t.Errorf("Missing range total should be -1: %d", n)
+	}
+}
@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const summaryMaxFragments = 100 // The number of broken fragments listed.
+
+// BrokenFragment is a link to an anchor that doesn't exist on its target page.
+type BrokenFragment struct {
+	URL            string   `json:"url"`            // The link, with its fragment.
+	Referrers      int      `json:"referrers"`      // How many pages link to it.
+	ReferringPages []string `json:"referringPages"` // The pages that link to it.
+}
+
+// fragmentLink records a link to an anchor ex: #section or page.html#section, and
+// removes the fragment from the link so the target is only scanned once. It returns
+// false if the link only points within the page.
+func (a *bodyAnalyzer) fragmentLink(u *url.URL) bool {
+	if u.Fragment == "" {
+		return true
+	}
+	st := a.ScanJob.Stat
+	target := u
+	if base := st.pageURL(); base != nil {
+		target = base.ResolveReference(u)
+	}
+	link := target.String()
+	var found bool
+	for _, f := range st.Fragments {
+		found = found || f == link
+	}
+	if !found {
+		st.Fragments = append(st.Fragments, link)
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String() != ""
+}
+
+// anchorName records the id of an element, or the name of a legacy a element, as an
+// anchor links can point to.
+func (a *bodyAnalyzer) anchorName(name string) {
+	if name == "" || a.anchors[name] {
+		return
+	}
+	a.anchors[name] = true
+	a.ScanJob.Stat.Anchors = append(a.ScanJob.Stat.Anchors, name)
+}
+
+// fragmentExists returns true if a fragment points to an anchor. The empty fragment
+// and top point to the top of any page, and text fragments can't be checked.
+func fragmentExists(fragment string, anchors map[string]bool) bool {
+	return fragment == "" || strings.EqualFold(fragment, "top") ||
+		strings.HasPrefix(fragment, ":~:") || anchors[fragment]
+}
+
+// pageAnchors returns the anchors of a scanned page. Pages that were not scanned, or
+// didn't load, can't be checked and nil is returned.
+func (s *Scanner) pageAnchors(page string) map[string]bool {
+	results, err := s.Store.Results(page)
+	if err != nil {
+		s.log.Errorf("Unable to read results for %s: %s", page, err)
+		return nil
+	}
+	parents := make([]string, 0, len(results))
+	for k := range results {
+		parents = append(parents, k)
+	}
+	if len(parents) == 0 {
+		return nil
+	}
+	sort.Strings(parents)
+	st := results[parents[0]]
+	if !st.isPage() || st.StatusCode < 200 || st.StatusCode > 299 {
+		return nil
+	}
+	anchors := make(map[string]bool, len(st.Anchors))
+	for _, a := range st.Anchors {
+		anchors[a] = true
+	}
+	return anchors
+}
+
+// checkFragments checks the fragment links of the pages against the anchors of their
+// targets once all the results are in, and adds the broken ones to the summary.
+func (s *Scanner) checkFragments(sum *Summary, pages map[string][]string) {
+	anchors := make(map[string]map[string]bool)
+	broken := make(map[string][]string)
+	for page, links := range pages {
+		flagged := false
+		for _, link := range links {
+			u, err := url.Parse(link)
+			if err != nil {
+				continue
+			}
+			fragment := u.Fragment
+			u.Fragment = ""
+			u.RawFragment = ""
+			target := u.String()
+			if _, ok := anchors[target]; !ok {
+				anchors[target] = s.pageAnchors(target)
+			}
+			if anchors[target] == nil || fragmentExists(fragment, anchors[target]) {
+				continue
+			}
+			broken[link] = append(broken[link], page)
+			if !flagged {
+				flagged = true
+				sum.Violations[RuleFragmentBroken]++
+			}
+		}
+	}
+	sum.BrokenFragmentCount = len(broken)
+	for link, refs := range broken {
+		sort.Strings(refs)
+		sum.BrokenFragments = append(sum.BrokenFragments, &BrokenFragment{
+			URL:            link,
+			Referrers:      len(refs),
+			ReferringPages: refs,
+		})
+	}
+	sort.Stable(fragmentSort(sum.BrokenFragments))
+	if len(sum.BrokenFragments) > summaryMaxFragments {
+		sum.BrokenFragments = sum.BrokenFragments[:summaryMaxFragments]
+	}
+}
+
+// fragmentSort orders broken fragments by descending number of referrers, then by URL.
+type fragmentSort []*BrokenFragment
+
+func (s fragmentSort) Len() int      { return len(s) }
+func (s fragmentSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s fragmentSort) Less(i, j int) bool {
+	if s[i].Referrers != s[j].Referrers {
+		return s[i].Referrers > s[j].Referrers
+	}
+	return s[i].URL < s[j].URL
+}
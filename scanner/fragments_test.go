@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestFragmentsAnalyze(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://example.com/dir/page")
+	j := scanJobNew(u, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(`<h2 id="intro">Intro</h2><a name="legacy"></a>` +
+		`<div id="intro"></div><p name="notanchor"></p>` +
+		`<a href="#intro">Intro</a><a href="#intro">Again</a><a href="other#part">Other</a>` +
+		`<a href="/plain">Plain</a><map><area href="#map" alt="map"></map>`))
+	bodyAnalyzerNew(j).analyzeBody()
+	if expected := []string{"intro", "legacy"}; !reflect.DeepEqual(j.Stat.Anchors, expected) {
+		t.Errorf("Invalid anchors. Expected: %v Received: %v", expected, j.Stat.Anchors)
+	}
+	expected := []string{"http://example.com/dir/page#intro", "http://example.com/dir/other#part",
+		"http://example.com/dir/page#map"}
+	if !reflect.DeepEqual(j.Stat.Fragments, expected) {
+		t.Errorf("Invalid fragments. Expected: %v Received: %v", expected, j.Stat.Fragments)
+	}
+	children := []string{}
+	for _, c := range j.Children {
+		children = append(children, c.URL.String())
+	}
+	if expected := []string{"other", "/plain"}; !reflect.DeepEqual(children, expected) {
+		t.Errorf("Links should be queued without fragments. Expected: %v Received: %v", expected, children)
+	}
+}
+
+func TestSummarizeFragments(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	put := func(path string, status int, anchors []string, fragments ...string) {
+		u, _ := url.Parse("http://example.com" + path)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = status
+		st.Anchors = anchors
+		for _, f := range fragments {
+			st.Fragments = append(st.Fragments, "http://example.com"+f)
+		}
+		s.Store.PutResult(st)
+	}
+	put("/a", 200, []string{"top-news"}, "/a#top-news", "/a#gone", "/b#part", "/b#top", "/b#:~:text=hi")
+	put("/b", 200, []string{"part"}, "/a#gone", "/c#x", "/missing#x")
+	put("/c", 404, nil)
+
+	sum := s.Summarize()
+	expected := []*BrokenFragment{
+		{"http://example.com/a#gone", 2, []string{"http://example.com/a", "http://example.com/b"}},
+	}
+	if !reflect.DeepEqual(sum.BrokenFragments, expected) || sum.BrokenFragmentCount != 1 {
+		t.Errorf("Invalid broken fragments.")
+		for _, f := range sum.BrokenFragments {
+			t.Errorf("Received: %+v", f)
+		}
+	}
+	if sum.Violations[RuleFragmentBroken] != 2 {
+		t.Errorf("Broken fragments should be counted once per page: %v", sum.Violations)
+	}
+}
+
+func TestScanFragmentKeys(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		page     string
+		redirect string
+		href     string
+		expected string
+		message  string
+	}{
+		{"http://example.com/dir/index.html", "", "page.html#x",
+			"http://example.com/dir/page.html", "Relative fragment links should resolve against the page."},
+		{"http://example.com/dir/", "", "../page.html#x",
+			"http://example.com/page.html", "Parent fragment links should resolve against the page."},
+		{"http://example.com/old", "http://example.com/new/index.html", "page.html#x",
+			"http://example.com/new/page.html", "Fragment links should resolve against the redirected page."},
+	}
+
+	for _, tc := range tests {
+		s := New("example.com", testMaxRunMin, testMaxWorkers)
+		u, _ := url.Parse(tc.page)
+		j := scanJobNew(u, "html", s.RootURL)
+		j.Stat.StatusCode = 200
+		j.Stat.RedirectURL = tc.redirect
+		j.Body = ioutil.NopCloser(bytes.NewBufferString(`<a href="` + tc.href + `">Link</a>`))
+		bodyAnalyzerNew(j).analyzeBody()
+		s.evaluate(j)
+		if len(j.Children) != 1 || j.Children[0].URL.String() != tc.expected {
+			t.Errorf("%s Expected: %s Received: %v", tc.message, tc.expected, j.Children)
+			continue
+		}
+		target, _ := url.Parse(tc.expected)
+		st := StatsNew(target, "html", s.RootURL)
+		st.StatusCode = 200
+		s.Store.PutResult(st)
+		if sum := s.Summarize(); sum.BrokenFragmentCount != 1 ||
+			sum.BrokenFragments[0].URL != tc.expected+"#x" {
+			t.Errorf("%s The missing anchor should be reported: %+v", tc.message, sum.BrokenFragments)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package scanner
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	graphDeepClicks     = 3      // Pages more clicks than this from the root are deep.
+	graphDamping        = 0.85   // The PageRank damping factor.
+	graphMaxIterations  = 100    // The most PageRank iterations run.
+	graphTolerance      = 1.0e-9 // PageRank stops once scores change less than this.
+	summaryMaxGraphList = 20     // The number of deep, dead end and top ranked pages listed.
+)
+
+// GraphPage is the position of a page in the internal link graph.
+type GraphPage struct {
+	URL      string  `json:"url"`      // The page.
+	Depth    int     `json:"depth"`    // Clicks from the root page, -1 if it can't be reached.
+	Inlinks  int     `json:"inlinks"`  // Internal pages that link to it.
+	Outlinks int     `json:"outlinks"` // Internal pages it links to.
+	PageRank float64 `json:"pageRank"` // Its share of the internal PageRank, all pages sum to 1.
+}
+
+// LinkGraph is the analysis of the internal links between the pages of a site.
+type LinkGraph struct {
+	Pages         int          `json:"pages"`         // Pages in the graph.
+	Links         int          `json:"links"`         // Links between different pages.
+	MaxDepth      int          `json:"maxDepth"`      // The most clicks needed to reach a page.
+	Depths        map[int]int  `json:"depths"`        // Count of pages by click depth.
+	Unreachable   int          `json:"unreachable"`   // Pages that can't be reached from the root.
+	DeepPageCount int          `json:"deepPageCount"` // Pages only reachable by deep paths.
+	DeepPages     []*GraphPage `json:"deepPages"`     // The deepest pages.
+	DeadEndCount  int          `json:"deadEndCount"`  // Loaded pages without internal links.
+	DeadEnds      []*GraphPage `json:"deadEnds"`      // The dead end pages with the most inlinks.
+	TopPageRank   []*GraphPage `json:"topPageRank"`   // The pages with the highest PageRank.
+	pages         []*GraphPage // Every page ordered by URL, for the report.
+}
+
+// linkGraphNew is a factory for creating a new, empty LinkGraph instance.
+func linkGraphNew() *LinkGraph {
+	return &LinkGraph{
+		Depths:      make(map[int]int),
+		DeepPages:   []*GraphPage{},
+		DeadEnds:    []*GraphPage{},
+		TopPageRank: []*GraphPage{},
+		pages:       []*GraphPage{},
+	}
+}
+
+// graphBuilder collects the pages and links of a scan for analysis.
+type graphBuilder struct {
+	parents map[string][]string // The pages each page was found on.
+	loaded  map[string]bool     // Pages that loaded, which can link to others.
+}
+
+// graphBuilderNew is a factory for creating a new graphBuilder instance.
+func graphBuilderNew() *graphBuilder {
+	return &graphBuilder{
+		parents: make(map[string][]string),
+		loaded:  make(map[string]bool),
+	}
+}
+
+// addPage adds a page and the URLs it was found on to the graph.
+func (g *graphBuilder) addPage(u string, parents []string, loaded bool) {
+	g.parents[u] = parents
+	g.loaded[u] = loaded
+}
+
+// analyze computes the click depth from the root, link counts and PageRank of every page.
+// Only links between pages in the graph count.
+func (g *graphBuilder) analyze(root string) *LinkGraph {
+	lg := linkGraphNew()
+	urls := make([]string, 0, len(g.parents))
+	for u := range g.parents {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	index := make(map[string]int, len(urls))
+	for i, u := range urls {
+		index[u] = i
+		lg.pages = append(lg.pages, &GraphPage{URL: u, Depth: -1})
+	}
+	out := make([][]int, len(urls))
+	in := make([][]int, len(urls))
+	for i, u := range urls {
+		for _, p := range g.parents[u] {
+			j, ok := index[p]
+			if !ok || j == i {
+				continue
+			}
+			out[j] = append(out[j], i)
+			in[i] = append(in[i], j)
+			lg.Links++
+		}
+	}
+	for i, pg := range lg.pages {
+		pg.Inlinks = len(in[i])
+		pg.Outlinks = len(out[i])
+	}
+	lg.Pages = len(urls)
+
+	// Breadth first from the root gives the fewest clicks to each page.
+	if r, ok := index[root]; ok {
+		lg.pages[r].Depth = 0
+		queue := []int{r}
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+			for _, j := range out[i] {
+				if lg.pages[j].Depth < 0 {
+					lg.pages[j].Depth = lg.pages[i].Depth + 1
+					queue = append(queue, j)
+				}
+			}
+		}
+	}
+
+	for i, r := range pageRank(out, in) {
+		lg.pages[i].PageRank = r
+	}
+
+	for _, pg := range lg.pages {
+		switch {
+		case pg.Depth < 0:
+			lg.Unreachable++
+		default:
+			lg.Depths[pg.Depth]++
+			if pg.Depth > lg.MaxDepth {
+				lg.MaxDepth = pg.Depth
+			}
+			if pg.Depth > graphDeepClicks {
+				lg.DeepPageCount++
+				lg.DeepPages = append(lg.DeepPages, pg)
+			}
+		}
+		if pg.Outlinks == 0 && g.loaded[pg.URL] {
+			lg.DeadEndCount++
+			lg.DeadEnds = append(lg.DeadEnds, pg)
+		}
+	}
+	sort.Stable(depthSort(lg.DeepPages))
+	sort.Stable(inlinkSort(lg.DeadEnds))
+	lg.TopPageRank = append(lg.TopPageRank, lg.pages...)
+	sort.Stable(rankSort(lg.TopPageRank))
+	if len(lg.DeepPages) > summaryMaxGraphList {
+		lg.DeepPages = lg.DeepPages[:summaryMaxGraphList]
+	}
+	if len(lg.DeadEnds) > summaryMaxGraphList {
+		lg.DeadEnds = lg.DeadEnds[:summaryMaxGraphList]
+	}
+	if len(lg.TopPageRank) > summaryMaxGraphList {
+		lg.TopPageRank = lg.TopPageRank[:summaryMaxGraphList]
+	}
+	return lg
+}
+
+// pageRank returns the PageRank of the nodes of a graph, by power iteration. The rank of
+// nodes without links out is shared by all nodes.
+func pageRank(out [][]int, in [][]int) []float64 {
+	n := len(out)
+	rank := make([]float64, n)
+	if n == 0 {
+		return rank
+	}
+	for i := range rank {
+		rank[i] = 1 / float64(n)
+	}
+	next := make([]float64, n)
+	for iter := 0; iter < graphMaxIterations; iter++ {
+		var dangling float64
+		for i := range out {
+			if len(out[i]) == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-graphDamping)/float64(n) + graphDamping*dangling/float64(n)
+		var delta float64
+		for i := range next {
+			sum := 0.0
+			for _, j := range in[i] {
+				sum += rank[j] / float64(len(out[j]))
+			}
+			next[i] = base + graphDamping*sum
+			delta += math.Abs(next[i] - rank[i])
+		}
+		rank, next = next, rank
+		if delta < graphTolerance {
+			break
+		}
+	}
+	return rank
+}
+
+// depthSort orders pages by descending click depth.
+type depthSort []*GraphPage
+
+func (s depthSort) Len() int           { return len(s) }
+func (s depthSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s depthSort) Less(i, j int) bool { return s[i].Depth > s[j].Depth }
+
+// inlinkSort orders pages by descending number of inlinks.
+type inlinkSort []*GraphPage
+
+func (s inlinkSort) Len() int           { return len(s) }
+func (s inlinkSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s inlinkSort) Less(i, j int) bool { return s[i].Inlinks > s[j].Inlinks }
+
+// rankSort orders pages by descending PageRank.
+type rankSort []*GraphPage
+
+func (s rankSort) Len() int           { return len(s) }
+func (s rankSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s rankSort) Less(i, j int) bool { return s[i].PageRank > s[j].PageRank }
@@ -0,0 +1,294 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// exportNode is a URL, or a directory of URLs, in an exported link graph.
+type exportNode struct {
+	ID      string // The URL, or the directory URL when collapsed.
+	URLType string // The type of url ex: html, img, or dir when collapsed.
+	Status  int    // The status code, the worst one when collapsed.
+	Depth   int    // Clicks from the root page, -1 if it can't be reached.
+	Issues  int    // The number of rule violations.
+	URLs    int    // How many URLs the node stands for.
+}
+
+// exportEdge is a reference from a page to a URL in an exported link graph.
+type exportEdge struct {
+	From    string // The referring page.
+	To      string // The URL referred to.
+	URLType string // The type of reference ex: html, img, css.
+	Text    string // The link text, of the first link when there are several.
+	Weight  int    // How many references the edge stands for.
+}
+
+// statusRank orders status codes from healthy to broken. Failed requests are the worst.
+func statusRank(code int) int {
+	if code < 100 {
+		return 1000
+	}
+	return code
+}
+
+// statusColor returns the red, green and blue colour of a node with a status code: green
+// for 2xx, blue for 3xx, orange for 4xx and red for 5xx and failed requests.
+func statusColor(code int) [3]uint8 {
+	switch statusClass(code) {
+	case "2xx":
+		return [3]uint8{0x2c, 0xa0, 0x2c}
+	case "3xx":
+		return [3]uint8{0x1f, 0x77, 0xb4}
+	case "4xx":
+		return [3]uint8{0xff, 0x7f, 0x0e}
+	}
+	return [3]uint8{0xd6, 0x27, 0x28}
+}
+
+// hexColor returns a colour in hex notation ex: #2ca02c.
+func hexColor(c [3]uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
+
+// directoryOf returns the directory URL of a URL ex: http://example.com/a/b.html =>
+// http://example.com/a/.
+func directoryOf(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return rawurl
+	}
+	dir := u.Path[:strings.LastIndex(u.Path, "/")+1]
+	if dir == "" {
+		dir = "/"
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: dir}).String()
+}
+
+// exportGraph returns the nodes and edges of the link graph to export, ordered by URL.
+func (s *Scanner) exportGraph() ([]*exportNode, []*exportEdge, error) {
+	nodes := make(map[string]*exportNode)
+	parents := make(map[string][]string)
+	texts := make(map[string]map[string]string)
+	graph := graphBuilderNew()
+	err := s.Store.EachResult(func(u string, results map[string]*Stats) error {
+		ps := make([]string, 0, len(results))
+		for p := range results {
+			ps = append(ps, p)
+		}
+		if len(ps) == 0 {
+			return nil
+		}
+		sort.Strings(ps)
+		stat := results[ps[0]]
+		if s.GraphPagesOnly && (!stat.isPage() || stat.URL.Host != s.RootURL.Host) {
+			return nil
+		}
+		nodes[u] = &exportNode{
+			ID:      u,
+			URLType: stat.URLType,
+			Status:  stat.StatusCode,
+			Issues:  len(stat.Violations()),
+			URLs:    1,
+		}
+		parents[u] = ps
+		for _, l := range stat.Links {
+			if texts[u] == nil {
+				texts[u] = make(map[string]string)
+			}
+			if _, ok := texts[u][l.URL]; !ok {
+				texts[u][l.URL] = l.Text
+			}
+		}
+		graph.addPage(u, ps, true)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pg := range graph.analyze(s.RootURL.String()).pages {
+		nodes[pg.URL].Depth = pg.Depth
+	}
+
+	// Only references between exported URLs are edges, collapsed into directories if asked.
+	key := func(u string) string { return u }
+	if s.GraphByDir {
+		key = directoryOf
+	}
+	merged := make(map[string]*exportNode)
+	edges := make(map[[3]string]*exportEdge)
+	for u, n := range nodes {
+		id := key(u)
+		m := merged[id]
+		switch {
+		case m == nil:
+			m = &exportNode{ID: id, URLType: n.URLType, Status: n.Status, Depth: n.Depth}
+			if s.GraphByDir {
+				m.URLType = "dir"
+			}
+			merged[id] = m
+		default:
+			if statusRank(n.Status) > statusRank(m.Status) {
+				m.Status = n.Status
+			}
+			if n.Depth >= 0 && (m.Depth < 0 || n.Depth < m.Depth) {
+				m.Depth = n.Depth
+			}
+		}
+		m.Issues += n.Issues
+		m.URLs++
+		for _, p := range parents[u] {
+			if nodes[p] == nil || key(p) == id {
+				continue
+			}
+			k := [3]string{key(p), id, n.URLType}
+			if edges[k] == nil {
+				edges[k] = &exportEdge{From: k[0], To: k[1], URLType: k[2], Text: texts[p][u]}
+			} else if s.GraphByDir {
+				edges[k].Text = "" // A collapsed edge has no single link text.
+			}
+			edges[k].Weight++
+		}
+	}
+
+	ns := make([]*exportNode, 0, len(merged))
+	for _, n := range merged {
+		ns = append(ns, n)
+	}
+	sort.Sort(exportNodeSort(ns))
+	es := make([]*exportEdge, 0, len(edges))
+	for _, e := range edges {
+		es = append(es, e)
+	}
+	sort.Sort(exportEdgeSort(es))
+	return ns, es, nil
+}
+
+// dotQuote returns a string as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+// writeDOT writes a link graph in the Graphviz DOT language.
+func writeDOT(w io.Writer, name string, nodes []*exportNode, edges []*exportEdge, color bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(name))
+	fmt.Fprintf(bw, "  node [shape=box];\n")
+	for _, n := range nodes {
+		fmt.Fprintf(bw, "  %s [type=%s, status=%d, depth=%d, issues=%d, urls=%d",
+			dotQuote(n.ID), dotQuote(n.URLType), n.Status, n.Depth, n.Issues, n.URLs)
+		if color {
+			fmt.Fprintf(bw, ", style=filled, fillcolor=%s", dotQuote(hexColor(statusColor(n.Status))))
+		}
+		fmt.Fprintf(bw, "];\n")
+	}
+	for _, e := range edges {
+		fmt.Fprintf(bw, "  %s -> %s [type=%s, text=%s, weight=%d];\n",
+			dotQuote(e.From), dotQuote(e.To), dotQuote(e.URLType), dotQuote(e.Text), e.Weight)
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
+
+// xmlEscape returns a string escaped for xml text and attributes.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeGraphML writes a link graph as GraphML. Colours are written as a hex colour and as
+// the r, g and b attributes Gephi reads.
+func writeGraphML(w io.Writer, nodes []*exportNode, edges []*exportEdge, color bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(bw, "<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n")
+	keys := [][3]string{
+		{"type", "node", "string"}, {"status", "node", "int"}, {"depth", "node", "int"},
+		{"issues", "node", "int"}, {"urls", "node", "int"},
+	}
+	if color {
+		keys = append(keys, [3]string{"color", "node", "string"}, [3]string{"r", "node", "int"},
+			[3]string{"g", "node", "int"}, [3]string{"b", "node", "int"})
+	}
+	keys = append(keys, [3]string{"linkType", "edge", "string"}, [3]string{"text", "edge", "string"},
+		[3]string{"weight", "edge", "double"})
+	for _, k := range keys {
+		name := k[0]
+		if name == "linkType" {
+			name = "type"
+		}
+		fmt.Fprintf(bw, "  <key id=\"%s\" for=\"%s\" attr.name=\"%s\" attr.type=\"%s\"/>\n", k[0], k[1], name, k[2])
+	}
+	fmt.Fprintf(bw, "  <graph id=\"G\" edgedefault=\"directed\">\n")
+	for _, n := range nodes {
+		fmt.Fprintf(bw, "    <node id=\"%s\">", xmlEscape(n.ID))
+		fmt.Fprintf(bw, "<data key=\"type\">%s</data><data key=\"status\">%d</data>", xmlEscape(n.URLType), n.Status)
+		fmt.Fprintf(bw, "<data key=\"depth\">%d</data><data key=\"issues\">%d</data>", n.Depth, n.Issues)
+		fmt.Fprintf(bw, "<data key=\"urls\">%d</data>", n.URLs)
+		if color {
+			c := statusColor(n.Status)
+			fmt.Fprintf(bw, "<data key=\"color\">%s</data><data key=\"r\">%d</data>", hexColor(c), c[0])
+			fmt.Fprintf(bw, "<data key=\"g\">%d</data><data key=\"b\">%d</data>", c[1], c[2])
+		}
+		fmt.Fprintf(bw, "</node>\n")
+	}
+	for _, e := range edges {
+		fmt.Fprintf(bw, "    <edge source=\"%s\" target=\"%s\">", xmlEscape(e.From), xmlEscape(e.To))
+		fmt.Fprintf(bw, "<data key=\"linkType\">%s</data><data key=\"text\">%s</data>", xmlEscape(e.URLType), xmlEscape(e.Text))
+		fmt.Fprintf(bw, "<data key=\"weight\">%d</data></edge>\n", e.Weight)
+	}
+	fmt.Fprintf(bw, "  </graph>\n</graphml>\n")
+	return bw.Flush()
+}
+
+// writeGraph writes the link graph of the scan to a file, as GraphML if the file name
+// ends in .graphml and in the DOT language otherwise.
+func (s *Scanner) writeGraph(path string) error {
+	nodes, edges, err := s.exportGraph()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".graphml") {
+		err = writeGraphML(f, nodes, edges, s.GraphColor)
+	} else {
+		err = writeDOT(f, s.RootURL.Host, nodes, edges, s.GraphColor)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// exportNodeSort orders nodes by URL.
+type exportNodeSort []*exportNode
+
+func (s exportNodeSort) Len() int           { return len(s) }
+func (s exportNodeSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s exportNodeSort) Less(i, j int) bool { return s[i].ID < s[j].ID }
+
+// exportEdgeSort orders edges by referring page, then URL and type.
+type exportEdgeSort []*exportEdge
+
+func (s exportEdgeSort) Len() int      { return len(s) }
+func (s exportEdgeSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s exportEdgeSort) Less(i, j int) bool {
+	if s[i].From != s[j].From {
+		return s[i].From < s[j].From
+	}
+	if s[i].To != s[j].To {
+		return s[i].To < s[j].To
+	}
+	return s[i].URLType < s[j].URLType
+}
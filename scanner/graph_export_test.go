@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testGraphScanner returns a scanner with a small crawl in its store.
+func testGraphScanner() *Scanner {
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com/blog/a", "html", "http://example.com", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com/blog/b", "html", "http://example.com", 404, time.Millisecond)
+	testSummaryStat(s, "http://example.com/blog/b", "html", "http://example.com/blog/a", 404, time.Millisecond)
+	testSummaryStat(s, "http://example.com/img/x.jpg", "img", "http://example.com/blog/a", 200, time.Millisecond)
+	testSummaryStat(s, "http://other.com/", "html", "http://example.com/blog/a", 500, time.Millisecond)
+	return s
+}
+
+func TestDirectoryOf(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://example.com", "http://example.com/"},
+		{"http://example.com/a.html", "http://example.com/"},
+		{"http://example.com/blog/a?page=2", "http://example.com/blog/"},
+		{"http://example.com/blog/", "http://example.com/blog/"},
+	}
+	for _, tc := range tests {
+		if d := directoryOf(tc.url); d != tc.expected {
+			t.Errorf("Invalid directory for %s. Expected %s, received %s.", tc.url, tc.expected, d)
+		}
+	}
+}
+
+func TestExportGraph(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		pagesOnly     bool
+		byDir         bool
+		expectedNodes []string
+		expectedEdges []string
+		message       string
+	}{
+		{false, false,
+			[]string{"http://example.com html 200 0", "http://example.com/blog/a html 200 1",
+				"http://example.com/blog/b html 404 1", "http://example.com/img/x.jpg img 200 2",
+				"http://other.com/ html 500 2"},
+			[]string{"http://example.com>http://example.com/blog/a html 1",
+				"http://example.com>http://example.com/blog/b html 1",
+				"http://example.com/blog/a>http://example.com/blog/b html 1",
+				"http://example.com/blog/a>http://example.com/img/x.jpg img 1",
+				"http://example.com/blog/a>http://other.com/ html 1"},
+			"Every URL should have been exported."},
+		{true, false,
+			[]string{"http://example.com html 200 0", "http://example.com/blog/a html 200 1",
+				"http://example.com/blog/b html 404 1"},
+			[]string{"http://example.com>http://example.com/blog/a html 1",
+				"http://example.com>http://example.com/blog/b html 1",
+				"http://example.com/blog/a>http://example.com/blog/b html 1"},
+			"Only internal pages should have been exported."},
+		{true, true,
+			[]string{"http://example.com/ dir 200 0", "http://example.com/blog/ dir 404 1"},
+			[]string{"http://example.com/>http://example.com/blog/ html 2"},
+			"Pages should have been collapsed into directories."},
+	}
+	for _, tc := range tests {
+		s := testGraphScanner()
+		s.GraphPagesOnly = tc.pagesOnly
+		s.GraphByDir = tc.byDir
+		nodes, edges, err := s.exportGraph()
+		if err != nil {
+			t.Fatalf("Unable to export graph: %s", err)
+		}
+		ns := []string{}
+		for _, n := range nodes {
+			ns = append(ns, fmt.Sprintf("%s %s %d %d", n.ID, n.URLType, n.Status, n.Depth))
+		}
+		es := []string{}
+		for _, e := range edges {
+			es = append(es, fmt.Sprintf("%s>%s %s %d", e.From, e.To, e.URLType, e.Weight))
+		}
+		if strings.Join(ns, ",") != strings.Join(tc.expectedNodes, ",") ||
+			strings.Join(es, ",") != strings.Join(tc.expectedEdges, ",") {
+			t.Errorf("%s\nNodes: %v\nEdges: %v", tc.message, ns, es)
+		}
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	t.Parallel()
+	nodes := []*exportNode{{ID: `http://example.com/"q"`, URLType: "html", Status: 404, URLs: 1}}
+	edges := []*exportEdge{{From: "a", To: "b", URLType: "html", Text: `Read "more"`, Weight: 2}}
+	var b bytes.Buffer
+	if err := writeDOT(&b, "example.com", nodes, edges, true); err != nil {
+		t.Fatalf("Unable to write DOT: %s", err)
+	}
+	expected := `digraph "example.com" {
+  node [shape=box];
+  "http://example.com/\"q\"" [type="html", status=404, depth=0, issues=0, urls=1, style=filled, fillcolor="#ff7f0e"];
+  "a" -> "b" [type="html", text="Read \"more\"", weight=2];
+}
+`
+	if b.String() != expected {
+		t.Errorf("Invalid DOT.\nExpected: %s\nReceived: %s", expected, b.String())
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := testGraphScanner()
+	s.GraphColor = true
+	path := filepath.Join(dir, "site.graphml")
+	if err := s.writeGraph(path); err != nil {
+		t.Fatalf("Unable to write graph: %s", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read graph: %s", err)
+	}
+	var g struct {
+		Keys  []struct{} `xml:"key"`
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(b, &g); err != nil {
+		t.Fatalf("Invalid GraphML: %s", err)
+	}
+	if len(g.Keys) != 12 || len(g.Graph.Nodes) != 5 || len(g.Graph.Edges) != 5 {
+		t.Errorf("Invalid GraphML: %d keys, %d nodes, %d edges", len(g.Keys), len(g.Graph.Nodes), len(g.Graph.Edges))
+	}
+	if !strings.Contains(string(b), `<data key="color">#d62728</data>`) {
+		t.Errorf("Nodes should have been coloured by status.")
+	}
+}
@@ -0,0 +1,120 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageRank(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		out      [][]int
+		expected []float64
+		message  string
+	}{
+		{[][]int{}, []float64{}, "Empty graphs should have no ranks."},
+		{[][]int{{1}, {0}}, []float64{0.5, 0.5}, "Symmetric pages should rank the same."},
+		{[][]int{{}, {}, {}}, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}, "Unlinked pages should rank the same."},
+		{[][]int{{2}, {2}, {}}, []float64{0.2128, 0.2128, 0.5745}, "Linked to pages should rank higher."},
+	}
+	for _, tc := range tests {
+		in := make([][]int, len(tc.out))
+		for i, links := range tc.out {
+			for _, j := range links {
+				in[j] = append(in[j], i)
+			}
+		}
+		r := pageRank(tc.out, in)
+		if len(r) != len(tc.expected) {
+			t.Errorf("%s Expected %d ranks, received %d.", tc.message, len(tc.expected), len(r))
+			continue
+		}
+		for i := range r {
+			if math.Abs(r[i]-tc.expected[i]) > 0.0001 {
+				t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, r)
+				break
+			}
+		}
+	}
+}
+
+func TestGraphAnalyze(t *testing.T) {
+	t.Parallel()
+	g := graphBuilderNew()
+	g.addPage("/", []string{"http:"}, true)
+	g.addPage("/a", []string{"/", "/b"}, true)
+	g.addPage("/b", []string{"/", "/b"}, true)
+	g.addPage("/c", []string{"/a"}, true)
+	g.addPage("/d", []string{"/c"}, true)
+	g.addPage("/e", []string{"/d"}, false)
+	g.addPage("/orphan", []string{"/unscanned"}, true)
+	lg := g.analyze("/")
+
+	if lg.Pages != 7 || lg.Links != 6 || lg.MaxDepth != 4 || lg.Unreachable != 1 {
+		t.Errorf("Invalid graph totals: %+v", lg)
+	}
+	depths := map[int]int{0: 1, 1: 2, 2: 1, 3: 1, 4: 1}
+	for d, n := range depths {
+		if lg.Depths[d] != n {
+			t.Errorf("Expected %d pages at depth %d, received %d.", n, d, lg.Depths[d])
+		}
+	}
+	if lg.DeepPageCount != 1 || lg.DeepPages[0].URL != "/e" {
+		t.Errorf("Pages more than %d clicks deep should have been listed.", graphDeepClicks)
+	}
+	ends := []string{}
+	for _, p := range lg.DeadEnds {
+		ends = append(ends, p.URL)
+	}
+	if strings.Join(ends, ",") != "/orphan" || lg.DeadEndCount != 1 {
+		t.Errorf("Only loaded pages without links should be dead ends: %v", ends)
+	}
+	rank := map[string]float64{}
+	var total float64
+	for _, p := range lg.pages {
+		rank[p.URL] = p.PageRank
+		total += p.PageRank
+		if p.URL == "/b" && (p.Inlinks != 1 || p.Outlinks != 1) {
+			t.Errorf("Self links should not be counted: %+v", p)
+		}
+	}
+	if rank["/orphan"] >= rank["/a"] || rank["/a"] >= rank["/c"] {
+		t.Errorf("Pages should rank by the links to them: %v", rank)
+	}
+	if math.Abs(total-1) > 0.0001 {
+		t.Errorf("PageRank should sum to 1, received %f.", total)
+	}
+}
+
+func TestSummarizeLinkGraph(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com/a", "html", "http://example.com", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com/x.jpg", "img", "http://example.com/a", 200, time.Millisecond)
+	sum := s.Summarize()
+	if sum.LinkGraph.Pages != 2 || sum.LinkGraph.Links != 1 || sum.LinkGraph.DeadEndCount != 1 {
+		t.Errorf("Only pages should be in the link graph: %+v", sum.LinkGraph)
+	}
+	if !strings.Contains(sum.Text(), "Link graph: 2 pages, 1 links") {
+		t.Errorf("The link graph should be in the text summary.")
+	}
+	var b bytes.Buffer
+	if err := s.encodeReport(&b, sum); err != nil {
+		t.Fatalf("Unable to encode report: %s", err)
+	}
+	var r Report
+	if err := json.Unmarshal(b.Bytes(), &r); err != nil {
+		t.Fatalf("Invalid json report: %s", err)
+	}
+	if len(r.Graph) != 2 || r.Graph[1].URL != "http://example.com/a" || r.Graph[1].Depth != 1 {
+		t.Errorf("Every page should be in the report graph.")
+	}
+	if r.Summary.LinkGraph.Pages != 2 {
+		t.Errorf("The link graph should be in the report summary.")
+	}
+}
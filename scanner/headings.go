@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxHeadings = 200 // The number of headings recorded in the outline of a page.
+)
+
+// Heading is an h1 to h6 element in the outline of a page.
+type Heading struct {
+	Level int    `json:"level"` // The heading level ex: 1 for h1.
+	Text  string `json:"text"`  // The text of the heading, including image alt text.
+}
+
+// headingLevel returns the level of a heading element ex: "h2" => 2, or 0 if the element
+// is not a heading.
+func headingLevel(name string) int {
+	if len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6' {
+		return int(name[1] - '0')
+	}
+	return 0
+}
+
+// headingFound starts a heading in the outline. Its text is read until the heading ends.
+func (a *bodyAnalyzer) headingFound(level int) {
+	if level == 1 {
+		a.checkH1()
+	}
+	a.heading = nil
+	if len(a.ScanJob.Stat.Headings) < maxHeadings {
+		a.heading = &Heading{Level: level}
+		a.ScanJob.Stat.Headings = append(a.ScanJob.Stat.Headings, a.heading)
+	}
+}
+
+// headingImage adds the alt text of an image inside a heading to the heading text.
+func (a *bodyAnalyzer) headingImage(tk html.Token) {
+	if a.heading == nil {
+		return
+	}
+	for _, attr := range tk.Attr {
+		if attr.Key == "alt" {
+			a.heading.Text += " " + attr.Val
+		}
+	}
+}
+
+// finishHeadings tidies the spacing of the heading text and records the text of the
+// first h1.
+func (a *bodyAnalyzer) finishHeadings() {
+	a.heading = nil
+	for _, h := range a.ScanJob.Stat.Headings {
+		h.Text = strings.Join(strings.Fields(h.Text), " ")
+		if h.Level == 1 && a.ScanJob.Stat.H1 == "" {
+			a.ScanJob.Stat.H1 = h.Text
+		}
+	}
+}
+
+// headingViolations returns the heading rules the outline of a page breaks.
+func (s *Stats) headingViolations() []string {
+	v := []string{}
+	var empty, skipped, sameAsTitle bool
+	var prev int // The first heading follows level 0, so it should be an h1.
+	title := strings.Join(strings.Fields(s.Title), " ")
+	for _, h := range s.Headings {
+		if h.Text == "" {
+			empty = true
+		}
+		// Going deeper more than one level at a time skips a level ex: h1 => h3.
+		if h.Level > prev+1 {
+			skipped = true
+		}
+		prev = h.Level
+		if h.Level == 1 && title != "" && strings.EqualFold(h.Text, title) {
+			sameAsTitle = true
+		}
+	}
+	if empty {
+		v = append(v, RuleHeadingEmpty)
+	}
+	if skipped {
+		v = append(v, RuleHeadingSkipped)
+	}
+	if sameAsTitle {
+		v = append(v, RuleH1Title)
+	}
+	return v
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestHeadingOutline(t *testing.T) {
+	t.Parallel()
+	s := `<h1>Red <em>Shoes</em></h1><h2><img src="/logo.png" alt="Logo"/></h2>` +
+		`<h3><img src="/icon.png"></h3><p>text</p><h2>  More
+		shoes </h2><h7>Not a heading</h7>`
+	j := scanJobNew(testURLRoot, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(s))
+	a := bodyAnalyzerNew(j)
+	a.analyzeBody()
+	expected := []*Heading{
+		{1, "Red Shoes"},
+		{2, "Logo"},
+		{3, ""},
+		{2, "More shoes"},
+	}
+	if !reflect.DeepEqual(j.Stat.Headings, expected) {
+		t.Errorf("Invalid outline.")
+		for _, h := range j.Stat.Headings {
+			t.Errorf("Received: %+v", h)
+		}
+	}
+	if j.Stat.H1Count != 1 || j.Stat.H1 != "Red Shoes" {
+		t.Errorf("Invalid h1. Count: %d Text: %q", j.Stat.H1Count, j.Stat.H1)
+	}
+	if len(j.Children) != 2 {
+		t.Errorf("Images inside headings should have been found.")
+	}
+}
+
+func TestHeadingViolations(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		title    string
+		headings []*Heading
+		expected []string
+		message  string
+	}{
+		{"Shoes | Shop", []*Heading{{1, "Shoes"}, {2, "Red"}, {3, "Sizes"}, {2, "Blue"}}, []string{},
+			"Valid outline should not report violations."},
+		{"Shoes", []*Heading{{1, "Shoes"}, {2, ""}}, []string{RuleHeadingEmpty, RuleH1Title},
+			"Empty heading and h1 same as title should have been reported."},
+		{"", []*Heading{{1, "Shoes"}, {4, "Sizes"}}, []string{RuleHeadingSkipped},
+			"Skipped level should have been reported."},
+		{"", []*Heading{{3, "Sizes"}, {1, "Shoes"}}, []string{RuleHeadingSkipped},
+			"First heading deeper than h1 should have been reported."},
+		{"", []*Heading{{1, "Shoes"}, {2, "Red"}, {3, "Sizes"}, {1, "Top"}}, []string{},
+			"Going back up should not be reported."},
+		{"Red  shoes", []*Heading{{1, "red shoes"}}, []string{RuleH1Title},
+			"Title comparison should ignore case and spacing."},
+	}
+	for _, tc := range tests {
+		st := StatsNew(testURLRoot, "html", nil)
+		st.Title = tc.title
+		st.Headings = tc.headings
+		if v := st.headingViolations(); !reflect.DeepEqual(v, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, v)
+		}
+	}
+}
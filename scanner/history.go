@@ -0,0 +1,76 @@
+package scanner
+
+import (
+	"encoding/json"
+	"errors"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	historyBucket = []byte("history") // Bucket of history entries keyed by URL.
+)
+
+// historyEntry is what a previous run learned about a URL.
+type historyEntry struct {
+	Stat     *Stats          `json:"stat"`     // Stats from the scan.
+	Children []*scanJobChild `json:"children"` // Child URLs found on the page.
+}
+
+// History holds the results of previous runs so unchanged URLs don't need to be
+// downloaded and analyzed again.
+type History struct {
+	db *bolt.DB // The database.
+}
+
+// OpenHistory opens or creates a History in a database file.
+func OpenHistory(path string) (*History, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: diskStoreTimeout})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(historyBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &History{db: db}, nil
+}
+
+// entry returns what a previous run learned about a URL, or nil if nothing is known.
+func (h *History) entry(u string) (*historyEntry, error) {
+	var e *historyEntry
+	err := h.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(historyBucket).Get([]byte(u))
+		if v == nil {
+			return nil
+		}
+		e = &historyEntry{}
+		return json.Unmarshal(v, e)
+	})
+	return e, err
+}
+
+// putEntry saves what this run learned about a URL.
+func (h *History) putEntry(u string, e *historyEntry) error {
+	v, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(historyBucket).Put([]byte(u), v)
+	})
+}
+
+// Close closes the database file.
+func (h *History) Close() error {
+	if h.db == nil {
+		return errors.New("History is already closed.")
+	}
+	err := h.db.Close()
+	h.db = nil
+	return err
+}
@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "history.db")
+
+	h, err := OpenHistory(path)
+	if err != nil {
+		t.Fatalf("Unable to open history: %s", err)
+	}
+	if e, err := h.entry("http://example.com"); e != nil || err != nil {
+		t.Errorf("Unknown URL should not have an entry.")
+	}
+	u, _ := url.Parse("http://example.com")
+	c, _ := url.Parse("/faq")
+	st := StatsNew(u, "html", nil)
+	st.ETag = `"abc"`
+	st.H1Count = 1
+	e := &historyEntry{Stat: st, Children: []*scanJobChild{{URL: c, URLType: "html"}}}
+	if err := h.putEntry(u.String(), e); err != nil {
+		t.Errorf("Unable to save entry: %s", err)
+	}
+	h.Close()
+	if err := h.Close(); err == nil {
+		t.Errorf("Closing twice should have failed.")
+	}
+
+	h, err = OpenHistory(path)
+	if err != nil {
+		t.Fatalf("Unable to reopen history: %s", err)
+	}
+	defer h.Close()
+	e, err = h.entry(u.String())
+	if err != nil || e == nil || e.Stat.ETag != `"abc"` || e.Stat.H1Count != 1 ||
+		len(e.Children) != 1 || e.Children[0].URL.String() != "/faq" {
+		t.Errorf("Entry should have survived reopening.")
+	}
+}
+
+func TestHistoryRecall(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	h, err := OpenHistory(filepath.Join(dir, "history.db"))
+	if err != nil {
+		t.Fatalf("Unable to open history: %s", err)
+	}
+	defer h.Close()
+
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.History = h
+	u, _ := url.Parse("http://example.com/a")
+	j := scanJobNew(u, "html", s.RootURL)
+	j.Stat.StatusCode = 200
+	s.remember(j)
+	s.recall(j)
+	if j.Prev != nil {
+		t.Errorf("URLs without validators should not be remembered.")
+	}
+
+	j.Stat.LastModified = "Wed, 21 Oct 2015 07:28:00 GMT"
+	s.remember(j)
+	k := scanJobNew(u, "html", s.RootURL)
+	s.recall(k)
+	if k.Prev == nil || k.Prev.Stat.LastModified != j.Stat.LastModified {
+		t.Errorf("URL should have been recalled.")
+	}
+}
@@ -0,0 +1,220 @@
+package scanner
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+const (
+	hreflangDefault    = "x-default" // The hreflang for pages that fit no other locale.
+	maxHreflangs       = 100         // The number of hreflang annotations recorded for a page.
+	summaryMaxHreflang = 100         // The number of hreflang problems listed.
+)
+
+// Alternate is an hreflang annotation naming the version of a page for a locale.
+type Alternate struct {
+	Lang string `json:"lang"` // The language and optional region ex: en, en-GB, x-default.
+	URL  string `json:"url"`  // The resolved URL of the alternate version.
+}
+
+// HreflangProblem is an hreflang annotation whose target doesn't agree with it.
+type HreflangProblem struct {
+	URL      string   `json:"url"`      // The page with the annotation.
+	Lang     string   `json:"lang"`     // The annotated locale.
+	Target   string   `json:"target"`   // The alternate URL.
+	Problems []string `json:"problems"` // Rules the target breaks.
+}
+
+// hreflangValid returns true if an hreflang is an ISO 639-1 language, optionally with an
+// ISO 15924 script and an ISO 3166-1 region ex: en, zh-Hant, en-GB, or x-default.
+func hreflangValid(lang string) bool {
+	if strings.EqualFold(lang, hreflangDefault) {
+		return true
+	}
+	parts := strings.Split(lang, "-")
+	if len(parts) > 3 || len(parts[0]) < 2 || len(parts[0]) > 3 {
+		return false
+	}
+	if _, err := language.ParseBase(parts[0]); err != nil {
+		return false
+	}
+	parts = parts[1:]
+	if len(parts) > 0 && len(parts[0]) == 4 {
+		if _, err := language.ParseScript(parts[0]); err != nil {
+			return false
+		}
+		parts = parts[1:]
+	}
+	switch len(parts) {
+	case 0:
+		return true
+	case 1:
+		// UK is reserved; the United Kingdom is GB.
+		r, err := language.ParseRegion(parts[0])
+		return err == nil && len(parts[0]) == 2 && r.IsCountry() && !strings.EqualFold(parts[0], "UK")
+	}
+	return false
+}
+
+// addHreflang records an hreflang annotation from a link element or Link header, and
+// scans its target so the annotation can be validated.
+func addHreflang(j *scanJob, lang string, href string) {
+	if len(j.Stat.Hreflang) >= maxHreflangs {
+		return
+	}
+	page := j.Stat.pageURL()
+	target, ok := resolveCanonical(page, href)
+	if !ok || href == "" {
+		return
+	}
+	j.Stat.Hreflang = append(j.Stat.Hreflang, &Alternate{Lang: strings.TrimSpace(lang), URL: target})
+	if target != page.String() {
+		u, _ := url.Parse(target)
+		j.Children = append(j.Children, &scanJobChild{URL: u, URLType: "html"})
+	}
+}
+
+// alternatesOf returns the hreflang annotations of a page, followed by those the sitemaps
+// add for it.
+func (s *Scanner) alternatesOf(u string, st *Stats) []*Alternate {
+	sitemap := s.sitemapHreflang[sitemapKey(u)]
+	if len(sitemap) == 0 {
+		return st.Hreflang
+	}
+	alts := append([]*Alternate{}, st.Hreflang...)
+	for _, a := range sitemap {
+		var found bool
+		for _, p := range st.Hreflang {
+			found = found || (strings.EqualFold(p.Lang, a.Lang) && p.URL == a.URL)
+		}
+		if !found {
+			alts = append(alts, a)
+		}
+	}
+	return alts
+}
+
+// hreflangHeader records the hreflang annotations of the Link header.
+func hreflangHeader(j *scanJob, h http.Header) {
+	for _, l := range linkHeader(h["Link"]) {
+		if l.rels("alternate") && l.Params["hreflang"] != "" {
+			addHreflang(j, l.Params["hreflang"], l.URL)
+		}
+	}
+}
+
+// hreflangViolations returns the hreflang rules the annotations of a page break.
+func (s *Stats) hreflangViolations() []string {
+	v := []string{}
+	if len(s.Hreflang) == 0 {
+		return v
+	}
+	var invalid, self, conflict bool
+	var defaults int
+	page := s.pageURL().String()
+	targets := make(map[string]string)
+	for _, alt := range s.Hreflang {
+		lang := strings.ToLower(alt.Lang)
+		if !hreflangValid(alt.Lang) {
+			invalid = true
+		}
+		if lang == hreflangDefault {
+			defaults++
+		}
+		if alt.URL == page {
+			self = true
+		}
+		if t, ok := targets[lang]; ok && t != alt.URL {
+			conflict = true
+		}
+		targets[lang] = alt.URL
+	}
+	if invalid {
+		v = append(v, RuleHreflangInvalid)
+	}
+	if !self {
+		v = append(v, RuleHreflangSelf)
+	}
+	if defaults > 1 {
+		v = append(v, RuleHreflangDefault)
+	}
+	if conflict {
+		v = append(v, RuleHreflangConflict)
+	}
+	return v
+}
+
+// hreflangProblems returns the rules the target of an hreflang annotation on a page
+// breaks. Targets that were not scanned can't be checked.
+func (s *Scanner) hreflangProblems(page string, target string) []string {
+	p := []string{}
+	results, err := s.Store.Results(target)
+	if err != nil {
+		s.log.Errorf("Unable to read results for %s: %s", target, err)
+		return p
+	}
+	parents := make([]string, 0, len(results))
+	for k := range results {
+		parents = append(parents, k)
+	}
+	if len(parents) == 0 {
+		return p
+	}
+	sort.Strings(parents)
+	st := results[parents[0]]
+	if st.StatusCode != http.StatusOK {
+		p = append(p, RuleHreflangStatus)
+	}
+	if c := canonicalOf(st); c != "" && c != target {
+		p = append(p, RuleHreflangCanonical)
+	}
+	var returned bool
+	for _, alt := range s.alternatesOf(target, st) {
+		returned = returned || alt.URL == page
+	}
+	if !returned {
+		p = append(p, RuleHreflangReturn)
+	}
+	return p
+}
+
+// checkHreflang validates the targets of the hreflang annotations of the pages once
+// all the results are in, and adds the problems found to the summary.
+func (s *Scanner) checkHreflang(sum *Summary, pages map[string][]*Alternate) {
+	urls := make([]string, 0, len(pages))
+	for u := range pages {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	for _, u := range urls {
+		flagged := make(map[string]bool)
+		for _, alt := range pages[u] {
+			if alt.URL == u {
+				continue
+			}
+			p := s.hreflangProblems(u, alt.URL)
+			if len(p) == 0 {
+				continue
+			}
+			for _, r := range p {
+				if !flagged[r] {
+					flagged[r] = true
+					sum.Violations[r]++
+				}
+			}
+			sum.HreflangProblemCount++
+			if len(sum.HreflangProblems) < summaryMaxHreflang {
+				sum.HreflangProblems = append(sum.HreflangProblems, &HreflangProblem{
+					URL:      u,
+					Lang:     alt.Lang,
+					Target:   alt.URL,
+					Problems: p,
+				})
+			}
+		}
+	}
+}
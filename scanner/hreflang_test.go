@@ -0,0 +1,203 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestHreflangValid(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		lang     string
+		expected bool
+	}{
+		{"en", true},
+		{"en-GB", true},
+		{"en-gb", true},
+		{"zh-Hant", true},
+		{"zh-Hant-TW", true},
+		{"x-default", true},
+		{"X-Default", true},
+		{"en-UK", false},
+		{"es-419", false},
+		{"english", false},
+		{"xx", false},
+		{"en-XX", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if v := hreflangValid(tc.lang); v != tc.expected {
+			t.Errorf("Invalid result for %q. Expected %t, received %t.", tc.lang, tc.expected, v)
+		}
+	}
+}
+
+func TestHreflangAnalyze(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/en/")
+	j := scanJobNew(u, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(`<link rel="alternate" hreflang="en" href="/en/">` +
+		`<link rel="alternate" hreflang="de" href="http://www.example.com/de/">` +
+		`<link rel="alternate" href="/feed.xml">`))
+	bodyAnalyzerNew(j).analyzeBody()
+	h := http.Header{}
+	h.Set("Link", `</fr/>; rel="alternate"; hreflang="fr", </>; rel="alternate"; hreflang="x-default"`)
+	hreflangHeader(j, h)
+	expected := []*Alternate{
+		{"en", "http://www.example.com/en/"},
+		{"de", "http://www.example.com/de/"},
+		{"fr", "http://www.example.com/fr/"},
+		{"x-default", "http://www.example.com/"},
+	}
+	if !reflect.DeepEqual(j.Stat.Hreflang, expected) {
+		t.Errorf("Invalid hreflang annotations.")
+		for _, alt := range j.Stat.Hreflang {
+			t.Errorf("Received: %+v", alt)
+		}
+	}
+	if len(j.Children) != 3 {
+		t.Errorf("Alternate pages other than the page itself should have been queued.")
+	}
+}
+
+func TestHreflangRedirected(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/faq")
+	j := scanJobNew(u, "html", nil)
+	j.Stat.RedirectURL = "http://www.example.com/en/faq"
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(`<link rel="alternate" hreflang="en" href="faq">` +
+		`<link rel="alternate" hreflang="de" href="/de/faq">`))
+	bodyAnalyzerNew(j).analyzeBody()
+	expected := []*Alternate{
+		{"en", "http://www.example.com/en/faq"},
+		{"de", "http://www.example.com/de/faq"},
+	}
+	if !reflect.DeepEqual(j.Stat.Hreflang, expected) {
+		t.Errorf("Annotations should be resolved against the redirect target.")
+		for _, alt := range j.Stat.Hreflang {
+			t.Errorf("Received: %+v", alt)
+		}
+	}
+	if len(j.Children) != 1 {
+		t.Errorf("The redirect target should not have been queued again.")
+	}
+	if v := j.Stat.hreflangViolations(); len(v) != 0 {
+		t.Errorf("The redirect target should count as the self reference: %v", v)
+	}
+}
+
+func TestHreflangViolations(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		alts     []*Alternate
+		expected []string
+		message  string
+	}{
+		{[]*Alternate{}, []string{}, "Pages without annotations should not report violations."},
+		{[]*Alternate{{"en", "http://www.example.com/faq"}, {"de", "http://www.example.com/de/faq"},
+			{"x-default", "http://www.example.com/faq"}}, []string{}, "Valid annotations should pass."},
+		{[]*Alternate{{"en-UK", "http://www.example.com/faq"}}, []string{RuleHreflangInvalid},
+			"Invalid code should have been reported."},
+		{[]*Alternate{{"de", "http://www.example.com/de/faq"}}, []string{RuleHreflangSelf},
+			"Missing self reference should have been reported."},
+		{[]*Alternate{{"en", "http://www.example.com/faq"}, {"x-default", "http://www.example.com/faq"},
+			{"X-Default", "http://www.example.com/other"}}, []string{RuleHreflangDefault, RuleHreflangConflict},
+			"Multiple x-default should have been reported."},
+		{[]*Alternate{{"en", "http://www.example.com/faq"}, {"en", "http://www.example.com/en/faq"}},
+			[]string{RuleHreflangConflict}, "Conflicting locale should have been reported."},
+	}
+	for _, tc := range tests {
+		u, _ := url.Parse("http://www.example.com/faq")
+		st := StatsNew(u, "html", nil)
+		st.Hreflang = tc.alts
+		if v := st.hreflangViolations(); !reflect.DeepEqual(v, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, v)
+		}
+	}
+}
+
+func TestSummarizeHreflang(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	put := func(path string, status int, canonical string, alts ...string) {
+		u, _ := url.Parse("http://example.com" + path)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = status
+		st.CanonicalURL = canonical
+		for i := 0; i < len(alts); i += 2 {
+			st.Hreflang = append(st.Hreflang, &Alternate{alts[i], "http://example.com" + alts[i+1]})
+		}
+		s.Store.PutResult(st)
+	}
+	put("/en", 200, "", "en", "/en", "de", "/de", "fr", "/fr", "es", "/es", "it", "/it")
+	put("/de", 200, "", "en", "/en", "de", "/de")
+	put("/fr", 200, "", "fr", "/fr")
+	put("/es", 404, "")
+	put("/it", 200, "http://example.com/en", "en", "/en")
+
+	sum := s.Summarize()
+	if sum.HreflangPages != 4 {
+		t.Errorf("Invalid hreflang pages: %d", sum.HreflangPages)
+	}
+	problems := map[string][]string{}
+	for _, h := range sum.HreflangProblems {
+		problems[h.URL+" "+h.Target] = h.Problems
+	}
+	expected := map[string][]string{
+		"http://example.com/en http://example.com/fr": {RuleHreflangReturn},
+		"http://example.com/en http://example.com/es": {RuleHreflangStatus, RuleHreflangReturn},
+		"http://example.com/en http://example.com/it": {RuleHreflangCanonical},
+	}
+	if !reflect.DeepEqual(problems, expected) || sum.HreflangProblemCount != 3 {
+		t.Errorf("Invalid hreflang problems: %v", problems)
+	}
+	if sum.Violations[RuleHreflangReturn] != 1 {
+		t.Errorf("Problems should be counted once per page: %v", sum.Violations)
+	}
+}
+
+func TestSummarizeSitemapHreflang(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.sitemap = map[string]bool{"http://example.com/en": true, "http://example.com/de": true}
+	s.sitemapHreflang = map[string][]*Alternate{
+		"http://example.com/en": {{"en", "http://example.com/en"}, {"de", "http://example.com/de"},
+			{"fr", "http://example.com/fr"}},
+		"http://example.com/de": {{"de", "http://example.com/de"}, {"en", "http://example.com/en"}},
+		"http://example.com/fr": {{"fr", "http://example.com/other"}},
+	}
+	put := func(path string, alts ...string) {
+		u, _ := url.Parse("http://example.com" + path)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = 200
+		for i := 0; i < len(alts); i += 2 {
+			st.Hreflang = append(st.Hreflang, &Alternate{alts[i], "http://example.com" + alts[i+1]})
+		}
+		s.Store.PutResult(st)
+	}
+	put("/en", "en", "/en")
+	put("/de")
+	put("/fr", "fr", "/fr")
+
+	sum := s.Summarize()
+	if sum.HreflangPages != 3 {
+		t.Errorf("Sitemap annotations should count as the page's: %d", sum.HreflangPages)
+	}
+	problems := map[string][]string{}
+	for _, h := range sum.HreflangProblems {
+		problems[h.URL+" "+h.Target] = h.Problems
+	}
+	expected := map[string][]string{
+		"http://example.com/en http://example.com/fr": {RuleHreflangReturn},
+	}
+	if !reflect.DeepEqual(problems, expected) {
+		t.Errorf("Invalid hreflang problems: %v", problems)
+	}
+	if sum.Violations[RuleHreflangConflict] != 1 {
+		t.Errorf("Sitemap annotations that disagree with the page should conflict: %v", sum.Violations)
+	}
+}
@@ -14,9 +14,12 @@ type scanJobChild struct {
 
 // scanJob is a transport packet that represents URL that needs processing.
 type scanJob struct {
-	Stat     *Stats          `json:"stat"`     // Stats from the scan.
-	Body     io.ReadCloser   `json:"body"`     // Body returned from the scan.
-	Children []*scanJobChild `json:"children"` // Child URLs found on the page.
+	Stat     *Stats           `json:"stat"`     // Stats from the scan.
+	Body     io.ReadCloser    `json:"body"`     // Body returned from the scan.
+	Children []*scanJobChild  `json:"children"` // Child URLs found on the page.
+	Prev     *historyEntry    `json:"-"`        // What a previous run learned about the URL.
+	trace    *requestTrace    // Timing of the last request sent for the URL.
+	robots   robotsDirectives // Indexing directives found for the URL.
 }
 
 // scanJobNew is a factory for creating a new job instance.
@@ -33,3 +36,16 @@ func (s *scanJob) String() string {
 	j, _ := json.Marshal(s)
 	return string(j)
 }
+
+// jobsByURL orders jobs by URL then parent URL.
+type jobsByURL []*scanJob
+
+func (j jobsByURL) Len() int      { return len(j) }
+func (j jobsByURL) Swap(a, b int) { j[a], j[b] = j[b], j[a] }
+func (j jobsByURL) Less(a, b int) bool {
+	ua, ub := j[a].Stat.URL.String(), j[b].Stat.URL.String()
+	if ua != ub {
+		return ua < ub
+	}
+	return j[a].Stat.ParentURL.String() < j[b].Stat.ParentURL.String()
+}
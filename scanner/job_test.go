@@ -13,8 +13,15 @@ const (
 		`"urlType":"html","parentURL":{"Scheme":"http","Opaque":"","User":null,"Host":` +
 		`"www.example.com","Path":"","RawQuery":"","Fragment":""},` +
 		`"startTime":"0001-01-01T00:00:00Z","endTime":"0001-01-01T00:00:00Z",` +
-		`"canonical":false,"metaCount":0,"metaSizedErr":false,"titleCount":0,"titleSizedErr":` +
-		`false,"altTagsErr":false,"h1Count":0,"status":0},"body":null,"children":[]}`
+		`"canonical":false,"canonicalURL":"",` +
+		`"metaCount":0,"metaSizedErr":false,"titleCount":0,"titleSizedErr":` +
+		`false,"altTagsErr":false,"h1Count":0,"status":0,"etag":"",` +
+		`"lastModified":"","reused":false,` +
+		`"method":"","contentType":"","contentLength":0,` +
+		`"servedType":"","charset":"","transferred":0,` +
+		`"timing":{"dns":0,"connect":0,"tls":0,"firstByte":0,"download":0,"total":0},"noindex":false,"nofollow":false,"canonicalHeader":"","redirectURL":"",` +
+		`"title":"","description":"","h1":"","headings":[],"openGraph":{},"twitterCard":{},"structuredData":[],"hreflang":[],"anchors":[],"fragments":[],"links":[],"insecure":[],"securityHeaders":{},` +
+		`"issues":[]},"body":null,"children":[]}`
 )
 
 func TestScanJobNew(t *testing.T) {
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"strings"
+)
+
+// headerLink is a link from a Link header ex: <http://example.com/>; rel="canonical".
+type headerLink struct {
+	URL    string            // The target of the link.
+	Params map[string]string // The link parameters with lower case names ex: rel, hreflang.
+}
+
+// linkHeader parses the links in the values of a Link header. Malformed links are skipped.
+func linkHeader(values []string) []*headerLink {
+	links := []*headerLink{}
+	for _, v := range values {
+		for {
+			start := strings.Index(v, "<")
+			end := strings.Index(v, ">")
+			if start < 0 || end < start {
+				break
+			}
+			l := &headerLink{URL: strings.TrimSpace(v[start+1 : end]), Params: make(map[string]string)}
+			v = v[end+1:]
+			// Parameters run until the comma that starts the next link.
+			var params string
+			params, v = splitLinkParams(v)
+			for _, p := range strings.Split(params, ";") {
+				kv := strings.SplitN(p, "=", 2)
+				k := strings.ToLower(strings.TrimSpace(kv[0]))
+				if k == "" {
+					continue
+				}
+				var val string
+				if len(kv) == 2 {
+					val = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+				}
+				if _, ok := l.Params[k]; !ok {
+					l.Params[k] = val
+				}
+			}
+			links = append(links, l)
+		}
+	}
+	return links
+}
+
+// splitLinkParams splits the parameters of a link from the rest of a Link header at the
+// first comma that is not quoted.
+func splitLinkParams(v string) (string, string) {
+	var quoted bool
+	for i, c := range v {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			return v[:i], v[i+1:]
+		}
+	}
+	return v, ""
+}
+
+// rels returns true if the link has a relation ex: rel="canonical". A link can have
+// several space separated relations.
+func (l *headerLink) rels(rel string) bool {
+	for _, r := range strings.Fields(l.Params["rel"]) {
+		if strings.EqualFold(r, rel) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestLinkHeader(t *testing.T) {
+	t.Parallel()
+	links := linkHeader([]string{
+		`<http://example.com/a>; rel="canonical", <http://example.com/b,c>; rel="alternate preload"; hreflang=de`,
+		`<http://example.com/d>; title="x, y"; rel=next`,
+		`malformed; rel=canonical`,
+	})
+	tests := []struct {
+		url      string
+		rel      string
+		hreflang string
+	}{
+		{"http://example.com/a", "canonical", ""},
+		{"http://example.com/b,c", "alternate", "de"},
+		{"http://example.com/d", "next", ""},
+	}
+	if len(links) != len(tests) {
+		t.Fatalf("Expected %d links, received %d.", len(tests), len(links))
+	}
+	for i, tc := range tests {
+		l := links[i]
+		if l.URL != tc.url || !l.rels(tc.rel) || l.Params["hreflang"] != tc.hreflang {
+			t.Errorf("Invalid link %d. Expected %s, received: %+v", i, tc.url, l)
+		}
+	}
+	if links[1].rels("canonical") {
+		t.Errorf("Link should not have the canonical relation.")
+	}
+}
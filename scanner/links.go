@@ -0,0 +1,229 @@
+package scanner
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxLinks                = 500 // The number of links recorded for a page.
+	summaryMaxAnchorTargets = 20  // The number of pages whose anchor text is listed.
+	summaryMaxAnchorTexts   = 10  // The number of anchor texts listed for a page.
+)
+
+// genericAnchors are link texts that say nothing about their target.
+var genericAnchors = map[string]bool{
+	"click":            true,
+	"click here":       true,
+	"continue":         true,
+	"continue reading": true,
+	"details":          true,
+	"go":               true,
+	"here":             true,
+	"learn more":       true,
+	"link":             true,
+	"more":             true,
+	"more info":        true,
+	"read more":        true,
+	"this":             true,
+	"this link":        true,
+	"this page":        true,
+}
+
+// Link is an a element on a page and the attributes search engines read.
+type Link struct {
+	URL    string `json:"url"`    // The resolved target, without its fragment.
+	Text   string `json:"text"`   // The link text, including image alt text.
+	Rel    string `json:"rel"`    // The rel attribute ex: nofollow noopener.
+	Title  string `json:"title"`  // The title attribute.
+	Target string `json:"target"` // The target attribute ex: _blank.
+}
+
+// AnchorText is a link text and how many links to a page use it.
+type AnchorText struct {
+	Text  string `json:"text"`  // The link text.
+	Count int    `json:"count"` // The number of links with the text.
+}
+
+// AnchorDistribution is the link text used by the internal links to a page.
+type AnchorDistribution struct {
+	URL   string        `json:"url"`   // The page linked to.
+	Links int           `json:"links"` // The number of internal links to the page.
+	Texts []*AnchorText `json:"texts"` // The most used link texts.
+}
+
+// hasRel returns true if the rel attribute of a link includes a value.
+func (l *Link) hasRel(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(l.Rel)) {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+// isGeneric returns true if the text of a link says nothing about its target.
+func (l *Link) isGeneric() bool {
+	t := strings.Trim(strings.ToLower(l.Text), " .:!?»›>…→")
+	return genericAnchors[t]
+}
+
+// linkFound starts a link. Its text is read until the link ends.
+func (a *bodyAnalyzer) linkFound(tk html.Token) {
+	a.link = nil
+	st := a.ScanJob.Stat
+	if len(st.Links) >= maxLinks {
+		return
+	}
+	l := &Link{}
+	var href, label string
+	var hasHref bool
+	for _, attr := range tk.Attr {
+		switch attr.Key {
+		case "href":
+			href, hasHref = attr.Val, true
+		case "rel":
+			l.Rel = attr.Val
+		case "title":
+			l.Title = attr.Val
+		case "target":
+			l.Target = attr.Val
+		case "aria-label":
+			label = attr.Val
+		}
+	}
+	u, err := url.Parse(strings.TrimSpace(href))
+	if !hasHref || err != nil {
+		return
+	}
+	if base := st.pageURL(); base != nil {
+		u = base.ResolveReference(u)
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	l.URL = u.String()
+	l.Text = label // An aria-label names the link in place of its text.
+	a.link = l
+	a.linkLabel = label != ""
+	st.Links = append(st.Links, l)
+}
+
+// linkText adds text inside a link to the link text.
+func (a *bodyAnalyzer) linkText(text string) {
+	if a.link != nil && !a.linkLabel {
+		a.link.Text += text
+	}
+}
+
+// linkImage adds the alt text of an image inside a link to the link text.
+func (a *bodyAnalyzer) linkImage(tk html.Token) {
+	for _, attr := range tk.Attr {
+		if attr.Key == "alt" {
+			a.linkText(" " + attr.Val + " ")
+		}
+	}
+}
+
+// finishLinks tidies the spacing of the link text.
+func (a *bodyAnalyzer) finishLinks() {
+	a.link = nil
+	for _, l := range a.ScanJob.Stat.Links {
+		l.Text = strings.Join(strings.Fields(l.Text), " ")
+	}
+}
+
+// linkViolations returns the link rules the links of a page break.
+func (s *Stats) linkViolations() []string {
+	v := []string{}
+	var generic, empty, nofollow, opener bool
+	for _, l := range s.Links {
+		generic = generic || l.isGeneric()
+		empty = empty || l.Text == ""
+		if u, err := url.Parse(l.URL); err == nil && s.pageURL() != nil && u.Host == s.pageURL().Host {
+			nofollow = nofollow || l.hasRel("nofollow")
+		}
+		// noreferrer implies noopener.
+		if strings.EqualFold(l.Target, "_blank") && !l.hasRel("noopener") && !l.hasRel("noreferrer") {
+			opener = true
+		}
+	}
+	if generic {
+		v = append(v, RuleAnchorGeneric)
+	}
+	if empty {
+		v = append(v, RuleAnchorEmpty)
+	}
+	if nofollow {
+		v = append(v, RuleNofollowInternal)
+	}
+	if opener {
+		v = append(v, RuleBlankNoopener)
+	}
+	return v
+}
+
+// anchorCounter counts the text of the internal links to each page.
+type anchorCounter map[string]map[string]int
+
+// add counts the internal links of a page.
+func (c anchorCounter) add(st *Stats) {
+	for _, l := range st.Links {
+		u, err := url.Parse(l.URL)
+		if err != nil || st.pageURL() == nil || u.Host != st.pageURL().Host {
+			continue
+		}
+		if c[l.URL] == nil {
+			c[l.URL] = make(map[string]int)
+		}
+		c[l.URL][l.Text]++
+	}
+}
+
+// distributions returns the link text of the most linked pages.
+func (c anchorCounter) distributions() []*AnchorDistribution {
+	ds := []*AnchorDistribution{}
+	for u, texts := range c {
+		d := &AnchorDistribution{URL: u, Texts: []*AnchorText{}}
+		for t, n := range texts {
+			d.Links += n
+			d.Texts = append(d.Texts, &AnchorText{Text: t, Count: n})
+		}
+		sort.Sort(anchorTextSort(d.Texts))
+		if len(d.Texts) > summaryMaxAnchorTexts {
+			d.Texts = d.Texts[:summaryMaxAnchorTexts]
+		}
+		ds = append(ds, d)
+	}
+	sort.Sort(anchorDistributionSort(ds))
+	if len(ds) > summaryMaxAnchorTargets {
+		ds = ds[:summaryMaxAnchorTargets]
+	}
+	return ds
+}
+
+// anchorTextSort orders link texts by descending count, then by text.
+type anchorTextSort []*AnchorText
+
+func (s anchorTextSort) Len() int      { return len(s) }
+func (s anchorTextSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s anchorTextSort) Less(i, j int) bool {
+	if s[i].Count != s[j].Count {
+		return s[i].Count > s[j].Count
+	}
+	return s[i].Text < s[j].Text
+}
+
+// anchorDistributionSort orders pages by descending number of links, then by URL.
+type anchorDistributionSort []*AnchorDistribution
+
+func (s anchorDistributionSort) Len() int      { return len(s) }
+func (s anchorDistributionSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s anchorDistributionSort) Less(i, j int) bool {
+	if s[i].Links != s[j].Links {
+		return s[i].Links > s[j].Links
+	}
+	return s[i].URL < s[j].URL
+}
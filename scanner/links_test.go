@@ -0,0 +1,134 @@
+package scanner
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestLinksAnalyze(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://example.com/dir/page")
+	j := scanJobNew(u, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(`<h2><a href="/shoes" rel="nofollow">Red
+		<b>Shoes</b></a></h2>` +
+		`<a href="next#part" title="Next" target="_blank"><img src="/n.png" alt="Next page"></a>` +
+		`<a href="/x" aria-label="Close menu">X</a><a name="top">Top</a><a href="">`))
+	bodyAnalyzerNew(j).analyzeBody()
+	expected := []*Link{
+		{"http://example.com/shoes", "Red Shoes", "nofollow", "", ""},
+		{"http://example.com/dir/next", "Next page", "", "Next", "_blank"},
+		{"http://example.com/x", "Close menu", "", "", ""},
+		{"http://example.com/dir/page", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(j.Stat.Links, expected) {
+		t.Errorf("Invalid links.")
+		for _, l := range j.Stat.Links {
+			t.Errorf("Received: %+v", l)
+		}
+	}
+	if len(j.Stat.Headings) != 1 || j.Stat.Headings[0].Text != "Red Shoes" {
+		t.Errorf("Link text should still be heading text.")
+	}
+}
+
+func TestLinkViolations(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://example.com/page")
+	tests := []struct {
+		link     *Link
+		expected []string
+		message  string
+	}{
+		{&Link{URL: "http://example.com/a", Text: "Red shoes"}, []string{}, "Good links should pass."},
+		{&Link{URL: "http://example.com/a", Text: "Read more »"}, []string{RuleAnchorGeneric},
+			"Generic text should be flagged."},
+		{&Link{URL: "http://example.com/a"}, []string{RuleAnchorEmpty}, "Empty text should be flagged."},
+		{&Link{URL: "http://example.com/a", Text: "A", Rel: "Nofollow"}, []string{RuleNofollowInternal},
+			"Internal nofollow should be flagged."},
+		{&Link{URL: "http://other.com/a", Text: "A", Rel: "nofollow"}, []string{},
+			"External nofollow should pass."},
+		{&Link{URL: "http://other.com/a", Text: "A", Target: "_blank"}, []string{RuleBlankNoopener},
+			"New windows without noopener should be flagged."},
+		{&Link{URL: "http://other.com/a", Text: "A", Target: "_blank", Rel: "noopener"}, []string{},
+			"New windows with noopener should pass."},
+		{&Link{URL: "http://other.com/a", Text: "A", Target: "_blank", Rel: "noreferrer"}, []string{},
+			"noreferrer implies noopener."},
+	}
+	for _, tc := range tests {
+		st := StatsNew(u, "html", nil)
+		st.Links = append(st.Links, tc.link)
+		if v := st.linkViolations(); !reflect.DeepEqual(v, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, v)
+		}
+	}
+}
+
+func TestSummarizeAnchorText(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	put := func(path string, links ...string) {
+		u, _ := url.Parse("http://example.com" + path)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = 200
+		for i := 0; i < len(links); i += 2 {
+			st.Links = append(st.Links, &Link{URL: links[i], Text: links[i+1]})
+		}
+		s.Store.PutResult(st)
+	}
+	put("/a", "http://example.com/shoes", "Shoes", "http://example.com/shoes", "Red shoes",
+		"http://other.com/", "Other")
+	put("/b", "http://example.com/shoes", "Shoes", "http://example.com/a", "Page A")
+	sum := s.Summarize()
+	expected := []*AnchorDistribution{
+		{"http://example.com/shoes", 3, []*AnchorText{{"Shoes", 2}, {"Red shoes", 1}}},
+		{"http://example.com/a", 1, []*AnchorText{{"Page A", 1}}},
+	}
+	if !reflect.DeepEqual(sum.AnchorText, expected) {
+		t.Errorf("Invalid anchor text.")
+		for _, d := range sum.AnchorText {
+			t.Errorf("Received: %+v", d)
+		}
+	}
+}
+
+func TestLinksScanKey(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	u, _ := url.Parse("http://example.com/old")
+	j := scanJobNew(u, "html", s.RootURL)
+	j.Stat.StatusCode = 200
+	j.Stat.RedirectURL = "http://example.com/blog/"
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(`<a href="post">Post</a>` +
+		`<a href="//example.com/about#team">About us</a>`))
+	bodyAnalyzerNew(j).analyzeBody()
+	s.evaluate(j)
+	for i, c := range j.Children {
+		if c.URL.String() != j.Stat.Links[i].URL {
+			t.Errorf("Links should use the scanned key. Expected: %s Received: %s", c.URL, j.Stat.Links[i].URL)
+		}
+		st := StatsNew(c.URL, "html", j.Stat.URL)
+		st.StatusCode = 200
+		s.Store.PutResult(st)
+	}
+
+	_, edges, err := s.exportGraph()
+	if err != nil {
+		t.Fatalf("Unable to export graph: %s", err)
+	}
+	es := []string{}
+	for _, e := range edges {
+		es = append(es, fmt.Sprintf("%s>%s %s", e.From, e.To, e.Text))
+	}
+	expected := []string{"http://example.com/old>http://example.com/about About us",
+		"http://example.com/old>http://example.com/blog/post Post"}
+	if !reflect.DeepEqual(es, expected) {
+		t.Errorf("Edges should have link text. Expected: %v Received: %v", expected, es)
+	}
+	if sum := s.Summarize(); len(sum.AnchorText) != 2 {
+		t.Errorf("Relative links should be counted: %+v", sum.AnchorText)
+	}
+}
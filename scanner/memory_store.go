@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"sort"
+)
+
+// memoryStore is a Store that keeps the frontier and results in memory.
+type memoryStore struct {
+	jobs    []*scanJob                   // The frontier.
+	results map[string]map[string]*Stats // URL test results [url][parent].
+}
+
+// memoryStoreNew is a factory for creating a new memoryStore instance.
+func memoryStoreNew() *memoryStore {
+	return &memoryStore{
+		jobs:    []*scanJob{},
+		results: make(map[string]map[string]*Stats),
+	}
+}
+
+// PushJob adds a job to the end of the frontier.
+func (m *memoryStore) PushJob(j *scanJob) error {
+	m.jobs = append(m.jobs, j)
+	return nil
+}
+
+// PopJob removes the first job from the frontier.
+func (m *memoryStore) PopJob() (*scanJob, error) {
+	if len(m.jobs) == 0 {
+		return nil, nil
+	}
+	j := m.jobs[0]
+	m.jobs[0] = nil
+	m.jobs = m.jobs[1:]
+	return j, nil
+}
+
+// EachJob visits the jobs in the frontier in order.
+func (m *memoryStore) EachJob(fn func(j *scanJob) error) error {
+	for _, j := range m.jobs {
+		if err := fn(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// JobLen returns the number of jobs in the frontier.
+func (m *memoryStore) JobLen() int {
+	return len(m.jobs)
+}
+
+// PutResult stores a result under its URL and parent.
+func (m *memoryStore) PutResult(st *Stats) error {
+	u := st.URL.String()
+	if _, ok := m.results[u]; !ok {
+		m.results[u] = make(map[string]*Stats)
+	}
+	m.results[u][st.ParentURL.String()] = st
+	return nil
+}
+
+// PutPage stores a result, if not nil, and adds the jobs found with it to the frontier.
+func (m *memoryStore) PutPage(st *Stats, jobs []*scanJob) error {
+	if st != nil {
+		m.PutResult(st)
+	}
+	m.jobs = append(m.jobs, jobs...)
+	return nil
+}
+
+// Result returns the result of a URL found on a parent.
+func (m *memoryStore) Result(u string, p string) (*Stats, error) {
+	return m.results[u][p], nil
+}
+
+// Results returns the results of a URL for every parent.
+func (m *memoryStore) Results(u string) (map[string]*Stats, error) {
+	parents := make(map[string]*Stats)
+	for p, st := range m.results[u] {
+		parents[p] = st
+	}
+	return parents, nil
+}
+
+// EachResult visits the results in URL order.
+func (m *memoryStore) EachResult(fn func(u string, parents map[string]*Stats) error) error {
+	urls := make([]string, 0, len(m.results))
+	for u := range m.results {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	for _, u := range urls {
+		if err := fn(u, m.results[u]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResultLen returns the number of URLs with results.
+func (m *memoryStore) ResultLen() int {
+	return len(m.results)
+}
+
+// Persistent returns false. Nothing survives a restart.
+func (m *memoryStore) Persistent() bool {
+	return false
+}
+
+// Reset removes all jobs and results.
+func (m *memoryStore) Reset() error {
+	m.jobs = []*scanJob{}
+	m.results = make(map[string]map[string]*Stats)
+	return nil
+}
+
+// Close is a NOP.
+func (m *memoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"net/url"
+	"testing"
+)
+
+// testStore runs the behaviour every Store implementation must share.
+func testStore(t *testing.T, st Store) {
+	root, _ := url.Parse("http://example.com")
+	a, _ := url.Parse("http://example.com/a")
+	b, _ := url.Parse("http://example.com/b")
+
+	// Frontier.
+	if j, err := st.PopJob(); j != nil || err != nil {
+		t.Errorf("Empty frontier should not return a job.")
+	}
+	st.PushJob(scanJobNew(a, "html", root))
+	st.PushJob(scanJobNew(b, "img", a))
+	if st.JobLen() != 2 {
+		t.Errorf("Invalid frontier length: %d", st.JobLen())
+	}
+	visited := 0
+	st.EachJob(func(j *scanJob) error {
+		visited++
+		return nil
+	})
+	if visited != 2 {
+		t.Errorf("Every job should have been visited.")
+	}
+	if err := st.EachJob(func(j *scanJob) error { return errStopEach }); err != errStopEach {
+		t.Errorf("Visitor error should have been returned.")
+	}
+	j, err := st.PopJob()
+	if err != nil || j == nil || j.Stat.URL.String() != a.String() ||
+		j.Stat.URLType != "html" || j.Stat.ParentURL.String() != root.String() {
+		t.Errorf("Jobs should be returned in order.")
+	}
+	st.PushJob(scanJobNew(root, "html", root))
+	j, _ = st.PopJob()
+	if j == nil || j.Stat.URL.String() != b.String() {
+		t.Errorf("Jobs should be returned in order.")
+	}
+	j, _ = st.PopJob()
+	if j == nil || j.Stat.URL.String() != root.String() || st.JobLen() != 0 {
+		t.Errorf("Jobs should be returned in order.")
+	}
+
+	// Results.
+	r := StatsNew(a, "html", root)
+	r.StatusCode = 200
+	st.PutResult(r)
+	st.PutResult(StatsNew(a, "html", b))
+	st.PutResult(StatsNew(b, "img", a))
+	if st.ResultLen() != 2 {
+		t.Errorf("Invalid result length: %d", st.ResultLen())
+	}
+	if r, err := st.Result(a.String(), root.String()); err != nil || r == nil || r.StatusCode != 200 {
+		t.Errorf("Result should have been found.")
+	}
+	if r, err := st.Result(a.String(), a.String()); err != nil || r != nil {
+		t.Errorf("Result should not have been found.")
+	}
+	if p, err := st.Results(a.String()); err != nil || len(p) != 2 || p[b.String()] == nil {
+		t.Errorf("Results for every parent should have been found.")
+	}
+	if p, err := st.Results("http://example.com"); err != nil || len(p) != 0 {
+		t.Errorf("Results should not have been found for a prefix.")
+	}
+	urls := []string{}
+	st.EachResult(func(u string, parents map[string]*Stats) error {
+		urls = append(urls, u)
+		return nil
+	})
+	if len(urls) != 2 || urls[0] != a.String() || urls[1] != b.String() {
+		t.Errorf("Results should be visited in URL order: %v", urls)
+	}
+
+	// Reset.
+	st.PushJob(scanJobNew(a, "html", root))
+	if err := st.Reset(); err != nil {
+		t.Errorf("Unable to reset store: %s", err)
+	}
+	if st.JobLen() != 0 || st.ResultLen() != 0 {
+		t.Errorf("Store should have been emptied.")
+	}
+	if j, _ := st.PopJob(); j != nil {
+		t.Errorf("Store should have been emptied.")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+	st := memoryStoreNew()
+	if st.Persistent() {
+		t.Errorf("Memory store should not be persistent.")
+	}
+	testStore(t, st)
+	if err := st.Close(); err != nil {
+		t.Errorf("Unable to close store: %s", err)
+	}
+}
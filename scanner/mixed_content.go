@@ -0,0 +1,62 @@
+package scanner
+
+const maxInsecure = 100 // The number of insecure URLs recorded for a page.
+
+// activeMixed are the URL types that can change a page when loaded over http. Browsers
+// block them on https pages.
+var activeMixed = map[string]bool{
+	"js":        true,
+	"css":       true,
+	"font":      true,
+	urlIframe:   true,
+	urlObject:   true,
+	urlManifest: true,
+	urlPreload:  true,
+}
+
+// passiveMixed are the URL types that can only be displayed when loaded over http.
+var passiveMixed = map[string]bool{
+	"img":     true,
+	urlSrcset: true,
+	urlPoster: true,
+	urlIcon:   true,
+	urlVideo:  true,
+	urlAudio:  true,
+}
+
+// mixedContent flags the http URLs referenced by a page served over https: active and
+// passive subresources, forms posting over http, and internal links to http pages. URLs
+// without a scheme are loaded over https, so only explicit http URLs are insecure.
+func (s *Scanner) mixedContent(job *scanJob) {
+	st := job.Stat
+	if st.pageURL().Scheme != "https" {
+		return
+	}
+	for _, c := range job.Children {
+		if c.URL.Scheme != "http" {
+			continue
+		}
+		var rule string
+		switch {
+		case activeMixed[c.URLType]:
+			rule = RuleMixedActive
+		case passiveMixed[c.URLType]:
+			rule = RuleMixedPassive
+		case c.URLType == urlForm:
+			rule = RuleFormInsecure
+		case isPageType(c.URLType) && c.URL.Host == s.RootURL.Host:
+			rule = RuleLinkInsecure
+		default:
+			continue
+		}
+		st.addIssue(rule)
+		u := c.URL.String()
+		var found bool
+		for _, i := range st.Insecure {
+			found = found || i == u
+		}
+		if !found && len(st.Insecure) < maxInsecure {
+			st.Insecure = append(st.Insecure, u)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestScanMixedContent(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		page     string
+		redirect string
+		child    string
+		urlType  string
+		expected []string
+		message  string
+	}{
+		{"https://example.com/", "", "http://cdn.com/a.js", "js", []string{RuleMixedActive},
+			"Scripts over http should be active mixed content."},
+		{"https://example.com/", "", "http://cdn.com/a.woff", "font", []string{RuleMixedActive},
+			"Fonts over http should be active mixed content."},
+		{"https://example.com/", "", "http://cdn.com/a.png", "srcset", []string{RuleMixedPassive},
+			"Images over http should be passive mixed content."},
+		{"https://example.com/", "", "http://example.com/search", "form", []string{RuleFormInsecure},
+			"Forms posting over http should be flagged."},
+		{"https://example.com/", "", "http://example.com/about", "html", []string{RuleLinkInsecure},
+			"Internal links to http should be flagged."},
+		{"https://example.com/", "", "http://other.com/", "html", []string{},
+			"External links to http should pass."},
+		{"https://example.com/", "", "/a.js", "js", []string{},
+			"URLs without a scheme should be loaded over https."},
+		{"http://example.com/", "https://example.com/", "http://cdn.com/a.css", "css", []string{RuleMixedActive},
+			"Pages redirected to https should be checked."},
+		{"http://example.com/", "", "http://cdn.com/a.js", "js", []string{},
+			"http pages should not be checked."},
+	}
+	for _, tc := range tests {
+		s := New("example.com", testMaxRunMin, testMaxWorkers)
+		u, _ := url.Parse(tc.page)
+		j := scanJobNew(u, "html", s.RootURL)
+		j.Stat.RedirectURL = tc.redirect
+		c, _ := url.Parse(tc.child)
+		j.Children = append(j.Children, &scanJobChild{URL: c, URLType: tc.urlType})
+		s.evaluate(j)
+		if !reflect.DeepEqual(j.Stat.Issues, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, j.Stat.Issues)
+		}
+		if len(tc.expected) > 0 && (len(j.Stat.Insecure) != 1 || j.Stat.Insecure[0] != tc.child) {
+			t.Errorf("%s Insecure URLs: %v", tc.message, j.Stat.Insecure)
+		}
+	}
+}
+
+func TestScanEvaluateScheme(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	u, _ := url.Parse("http://example.com/")
+	j := scanJobNew(u, "html", s.RootURL)
+	j.Stat.RedirectURL = "https://example.com/"
+	c, _ := url.Parse("/about")
+	j.Children = append(j.Children, &scanJobChild{URL: c, URLType: "html"})
+	s.evaluate(j)
+	if c := j.Children[0].URL.String(); c != "https://example.com/about" {
+		t.Errorf("Relative links on https pages should be https: %s", c)
+	}
+}
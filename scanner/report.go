@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Report is the complete result of a scan as written to the report file.
+type Report struct {
+	Partial bool         `json:"partial"` // Was the scan stopped before all URLs were scanned?
+	Summary *Summary     `json:"summary"` // The aggregated results of the scan.
+	Graph   []*GraphPage `json:"graph"`   // The position of every page in the link graph.
+	Results []*Stats     `json:"results"` // Every URL test result.
+}
+
+// writeReport writes the json encoded report of the scan to a file.
+func (s *Scanner) writeReport(path string, sum *Summary) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = s.encodeReport(w, sum)
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// encodeReport writes the json encoded Report one result at a time, so the results never
+// need to be held in memory together.
+func (s *Scanner) encodeReport(w io.Writer, sum *Summary) error {
+	js, err := json.Marshal(sum)
+	if err != nil {
+		return err
+	}
+	graph := sum.LinkGraph.pages
+	if graph == nil {
+		graph = []*GraphPage{}
+	}
+	gs, err := json.Marshal(graph)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, `{"partial":%t,"summary":%s,"graph":%s,"results":[`, sum.Partial, js, gs); err != nil {
+		return err
+	}
+	sep := ""
+	err = s.Store.EachResult(func(u string, results map[string]*Stats) error {
+		parents := make([]string, 0, len(results))
+		for p := range results {
+			parents = append(parents, p)
+		}
+		sort.Strings(parents)
+		for _, p := range parents {
+			js, err := json.Marshal(results[p])
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "%s%s", sep, js); err != nil {
+				return err
+			}
+			sep = ","
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
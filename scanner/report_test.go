@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReportEncode(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.StopReason = StopSignal
+	testSummaryStat(s, "http://example.com/b", "html", "http://example.com", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, time.Millisecond)
+	var b bytes.Buffer
+	if err := s.encodeReport(&b, s.Summarize()); err != nil {
+		t.Fatalf("Unable to encode report: %s", err)
+	}
+	var r Report
+	if err := json.Unmarshal(b.Bytes(), &r); err != nil {
+		t.Fatalf("Invalid json report: %s", err)
+	}
+	if !r.Partial {
+		t.Errorf("Report should have been marked partial.")
+	}
+	if len(r.Results) != 2 || r.Results[0].URL.String() != "http://example.com" {
+		t.Errorf("Results should have been sorted by URL.")
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "pzscan")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.StopReason = StopIdle
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, time.Millisecond)
+	path := filepath.Join(dir, "report.json")
+	if err := s.writeReport(path, s.Summarize()); err != nil {
+		t.Fatalf("Unable to write report: %s", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unable to read report: %s", err)
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil {
+		t.Fatalf("Invalid json report: %s", err)
+	}
+	if r.Partial || r.Summary.Pages != 1 || len(r.Results) != 1 {
+		t.Errorf("Report was not written correctly.")
+	}
+	if err := s.writeReport(filepath.Join(dir, "missing", "report.json"), s.Summarize()); err == nil {
+		t.Errorf("Writing to a missing directory should have failed.")
+	}
+}
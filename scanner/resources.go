@@ -0,0 +1,183 @@
+package scanner
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// URL types of references found on pages, besides html, img, css, js and font.
+const (
+	urlSrcset   = "srcset"   // An image candidate of img or picture source srcset.
+	urlVideo    = "video"    // video and video source src.
+	urlAudio    = "audio"    // audio and audio source src.
+	urlPoster   = "poster"   // The image shown before a video plays.
+	urlIframe   = "iframe"   // An embedded frame.
+	urlObject   = "object"   // object data and embed src.
+	urlIcon     = "icon"     // Favicons and apple-touch-icons.
+	urlManifest = "manifest" // A web app manifest.
+	urlPreload  = "preload"  // A resource the page asks to be preloaded.
+	urlPrefetch = "prefetch" // A resource the page asks to be prefetched.
+	urlForm     = "form"     // A form action.
+	urlArea     = "area"     // An image map link.
+	urlRefresh  = "refresh"  // The target of a meta refresh.
+)
+
+// urlServedTypes maps the URL types to the type they must be served as. URLs of other
+// types can be served as anything.
+var urlServedTypes = map[string]string{
+	"img":     servedImage,
+	"css":     servedCSS,
+	"js":      servedScript,
+	"font":    servedFont,
+	urlSrcset: servedImage,
+	urlPoster: servedImage,
+	urlIcon:   servedImage,
+	urlVideo:  servedVideo,
+	urlAudio:  servedAudio,
+}
+
+// isPageType returns true if URLs of a type are links to pages, which are crawled and
+// analyzed.
+func isPageType(ut string) bool {
+	return ut == "html" || ut == urlArea || ut == urlRefresh
+}
+
+// srcsetURLs returns the URLs of the image candidates in a srcset attribute
+// ex: "a.jpg 1x, b.jpg 2x" => a.jpg, b.jpg.
+func srcsetURLs(srcset string) []string {
+	urls := []string{}
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return urls
+		}
+		i := strings.IndexAny(s, " \t\n\r\f")
+		if i < 0 {
+			i = len(s)
+		}
+		u := s[:i]
+		s = s[i:]
+		if strings.HasSuffix(u, ",") {
+			// No descriptor, the comma ends the candidate.
+			u = strings.TrimRight(u, ",")
+		} else {
+			// Skip the descriptors, which may contain commas inside parentheses.
+			depth := 0
+			i = 0
+			for ; i < len(s); i++ {
+				if s[i] == '(' {
+					depth++
+				} else if s[i] == ')' && depth > 0 {
+					depth--
+				} else if s[i] == ',' && depth == 0 {
+					break
+				}
+			}
+			s = s[i:]
+		}
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+}
+
+// refreshURL returns the URL of a meta refresh content ex: "5; url='/next'" => /next.
+func refreshURL(content string) string {
+	i := strings.IndexAny(content, ";,")
+	if i < 0 {
+		return ""
+	}
+	s := strings.TrimSpace(content[i+1:])
+	if len(s) >= 3 && strings.EqualFold(s[:3], "url") {
+		if rest := strings.TrimSpace(s[3:]); strings.HasPrefix(rest, "=") {
+			s = strings.TrimSpace(rest[1:])
+		}
+	}
+	if len(s) > 0 && (s[0] == '\'' || s[0] == '"') {
+		if j := strings.IndexByte(s[1:], s[0]); j >= 0 {
+			s = s[1 : j+1]
+		} else {
+			s = s[1:]
+		}
+	}
+	return s
+}
+
+// resource records a URL referenced by a page as a child of the given type. The URL is
+// resolved against the page.
+func (a *bodyAnalyzer) resource(href, ut string) {
+	href = strings.TrimSpace(href)
+	lower := strings.ToLower(href)
+	if href == "" || strings.HasPrefix(lower, "data:") || strings.HasPrefix(lower, "javascript:") {
+		return
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return
+	}
+	if ut == urlArea && !a.fragmentLink(u) {
+		return
+	}
+	if base := a.ScanJob.Stat.pageURL(); base != nil {
+		u = base.ResolveReference(u)
+	}
+	a.ScanJob.Children = append(a.ScanJob.Children, &scanJobChild{URL: u, URLType: ut})
+}
+
+// resourceFound will scan an element for the responsive images, media, frames, icons,
+// preloads, forms, image map links and refreshes it references.
+func (a *bodyAnalyzer) resourceFound(tk html.Token) {
+	attrs := make(map[string]string)
+	for _, attr := range tk.Attr {
+		attrs[attr.Key] = attr.Val
+	}
+	switch tk.DataAtom.String() {
+	case "img":
+		for _, u := range srcsetURLs(attrs["srcset"]) {
+			a.resource(u, urlSrcset)
+		}
+	case "source":
+		for _, u := range srcsetURLs(attrs["srcset"]) {
+			a.resource(u, urlSrcset)
+		}
+		if a.media != "" {
+			a.resource(attrs["src"], a.media)
+		} else {
+			a.resource(attrs["src"], urlVideo)
+		}
+	case "video", "audio":
+		a.media = tk.DataAtom.String()
+		a.resource(attrs["src"], a.media)
+		a.resource(attrs["poster"], urlPoster)
+	case "iframe":
+		a.resource(attrs["src"], urlIframe)
+	case "object":
+		a.resource(attrs["data"], urlObject)
+	case "embed":
+		a.resource(attrs["src"], urlObject)
+	case "link":
+		for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+			switch rel {
+			case "icon", "apple-touch-icon", "apple-touch-icon-precomposed":
+				a.resource(attrs["href"], urlIcon)
+			case "manifest":
+				a.resource(attrs["href"], urlManifest)
+			case "preload", "modulepreload":
+				a.resource(attrs["href"], urlPreload)
+			case "prefetch":
+				a.resource(attrs["href"], urlPrefetch)
+			}
+		}
+	case "form":
+		a.resource(attrs["action"], urlForm)
+	case "area":
+		a.resource(attrs["href"], urlArea)
+	case "meta":
+		if strings.EqualFold(attrs["http-equiv"], "refresh") {
+			a.resource(refreshURL(attrs["content"]), urlRefresh)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSrcsetURLs(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		srcset   string
+		expected []string
+	}{
+		{"a.jpg", []string{"a.jpg"}},
+		{"a.jpg 1x, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{" a.jpg 480w,b.jpg 800w ", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg, b.jpg 2x", []string{"a.jpg", "b.jpg"}},
+		{"a.jpg,b.jpg", []string{"a.jpg,b.jpg"}},
+		{"a.jpg calc(1px, 2px), b.jpg", []string{"a.jpg", "b.jpg"}},
+		{"/img?w=1,2 1x", []string{"/img?w=1,2"}},
+		{"", []string{}},
+	}
+	for _, tc := range tests {
+		if u := srcsetURLs(tc.srcset); !reflect.DeepEqual(u, tc.expected) {
+			t.Errorf("Invalid URLs for %q. Expected: %v Received: %v", tc.srcset, tc.expected, u)
+		}
+	}
+}
+
+func TestRefreshURL(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"0; url=/next", "/next"},
+		{"5;URL='http://example.com/a b'", "http://example.com/a b"},
+		{`3, url = "/quoted"`, "/quoted"},
+		{"10; /bare", "/bare"},
+		{"30", ""},
+	}
+	for _, tc := range tests {
+		if u := refreshURL(tc.content); u != tc.expected {
+			t.Errorf("Invalid URL for %q. Expected: %q Received: %q", tc.content, tc.expected, u)
+		}
+	}
+}
+
+func TestBodyAnalyzerResources(t *testing.T) {
+	t.Parallel()
+	s := `<html><head>
+		<link rel="shortcut icon" href="/favicon.ico">
+		<link rel="apple-touch-icon" href="/touch.png">
+		<link rel="manifest" href="/app.webmanifest">
+		<link rel="preload" href="/font.woff2" as="font">
+		<link rel="prefetch" href="/next.html">
+		<meta http-equiv="Refresh" content="5; url=/moved">
+		</head><body>
+		<img src="/a.jpg" srcset="/a-2x.jpg 2x" alt="a">
+		<picture><source srcset="/b.webp 1x, /b-2x.webp 2x"></picture>
+		<video src="/v.mp4" poster="/v.jpg"><source src="/v.webm"></video>
+		<audio><source src="/a.mp3"></audio>
+		<iframe src="https://video.example.org/embed"></iframe>
+		<object data="/doc.pdf"></object><embed src="/anim.swf">
+		<form action="search"></form><form action=""></form>
+		<map><area href="/region" alt="region"></map>
+		<img srcset="data:image/png;base64,AAA 1x" alt="inline">
+		</body></html>`
+	u, _ := url.Parse("http://example.com/dir/page")
+	j := scanJobNew(u, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(s))
+	a := bodyAnalyzerNew(j)
+	a.analyzeBody()
+	received := []string{}
+	for _, c := range a.ScanJob.Children {
+		received = append(received, c.URLType+" "+c.URL.String())
+	}
+	expected := []string{
+		"icon http://example.com/favicon.ico",
+		"icon http://example.com/touch.png",
+		"manifest http://example.com/app.webmanifest",
+		"preload http://example.com/font.woff2",
+		"prefetch http://example.com/next.html",
+		"refresh http://example.com/moved",
+		"srcset http://example.com/a-2x.jpg",
+		"img /a.jpg",
+		"srcset http://example.com/b.webp",
+		"srcset http://example.com/b-2x.webp",
+		"video http://example.com/v.mp4",
+		"poster http://example.com/v.jpg",
+		"video http://example.com/v.webm",
+		"audio http://example.com/a.mp3",
+		"iframe https://video.example.org/embed",
+		"object http://example.com/doc.pdf",
+		"object http://example.com/anim.swf",
+		"form http://example.com/dir/search",
+		"area http://example.com/region",
+	}
+	if !reflect.DeepEqual(received, expected) {
+		t.Errorf("Invalid resources.\nExpected: %v\nReceived: %v", expected, received)
+	}
+}
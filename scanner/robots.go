@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"strings"
+)
+
+var (
+	// Robots directives that take a value ex: "max-snippet: 20". They are not bot names.
+	robotsValued = map[string]bool{
+		"max-snippet":       true,
+		"max-image-preview": true,
+		"max-video-preview": true,
+		"unavailable_after": true,
+	}
+)
+
+// robotsDirectives are the indexing directives found for a URL in its meta robots
+// elements and X-Robots-Tag headers.
+type robotsDirectives struct {
+	index    bool // Was the URL allowed to be indexed?
+	noindex  bool // Was the URL forbidden to be indexed?
+	follow   bool // Were the links allowed to be followed?
+	nofollow bool // Were the links forbidden to be followed?
+}
+
+// add records a list of directives ex: "noindex, nofollow".
+func (d *robotsDirectives) add(content string) {
+	for _, f := range strings.Split(content, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if i := strings.Index(f, ":"); i >= 0 {
+			f = strings.TrimSpace(f[:i])
+		}
+		switch f {
+		case "index":
+			d.index = true
+		case "noindex":
+			d.noindex = true
+		case "follow":
+			d.follow = true
+		case "nofollow":
+			d.nofollow = true
+		case "all":
+			d.index = true
+			d.follow = true
+		case "none":
+			d.noindex = true
+			d.nofollow = true
+		}
+	}
+}
+
+// addHeader records the directives of an X-Robots-Tag header. Directives for a named bot
+// ex: "googlebot: noindex" are only recorded for that agent.
+func (d *robotsDirectives) addHeader(v string, agent string) {
+	if i := strings.Index(v, ":"); i >= 0 {
+		prefix := strings.ToLower(strings.TrimSpace(v[:i]))
+		if !strings.ContainsAny(prefix, ", ") && !robotsValued[prefix] {
+			if prefix != strings.ToLower(agent) {
+				return
+			}
+			v = v[i+1:]
+		}
+	}
+	d.add(v)
+}
+
+// addMeta records the directives of a meta element if it is for all robots or the agent.
+func (d *robotsDirectives) addMeta(name string, content string, agent string) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "robots" || name == strings.ToLower(agent) {
+		d.add(content)
+	}
+}
+
+// apply sets the directives in the stats. The most restrictive directive wins, and
+// directives that contradict each other are flagged.
+func (d *robotsDirectives) apply(s *Stats) {
+	s.NoIndex = d.noindex
+	s.NoFollow = d.nofollow
+	if (d.index && d.noindex) || (d.follow && d.nofollow) {
+		s.addIssue(RuleRobotsConflict)
+	}
+}
@@ -0,0 +1,52 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRobotsDirectives(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		meta             []string
+		header           []string
+		expectedNoIndex  bool
+		expectedNoFollow bool
+		expectedConflict bool
+		message          string
+	}{
+		{nil, nil, false, false, false, "No directives should allow everything."},
+		{[]string{"robots", "noindex, nofollow"}, nil, true, true, false, "Meta robots should be recorded."},
+		{[]string{"robots", "NONE"}, nil, true, true, false, "None should forbid everything."},
+		{[]string{"googlebot", "noindex"}, nil, true, false, false, "Agent meta should be recorded."},
+		{[]string{"bingbot", "noindex"}, nil, false, false, false, "Other bots should be ignored."},
+		{[]string{"description", "noindex"}, nil, false, false, false, "Other meta should be ignored."},
+		{nil, []string{"noindex"}, true, false, false, "Header should be recorded."},
+		{nil, []string{"googlebot: nofollow"}, false, true, false, "Agent header should be recorded."},
+		{nil, []string{"otherbot: noindex, nofollow"}, false, false, false, "Other bot header should be ignored."},
+		{nil, []string{"noindex, unavailable_after: 25 Jun 2030 15:00:00 PST"}, true, false, false,
+			"Valued directives should not be taken as bot names."},
+		{nil, []string{"max-snippet: 20"}, false, false, false, "Valued directives should be skipped."},
+		{[]string{"robots", "index, follow"}, []string{"noindex"}, true, false, true,
+			"Contradicting directives should be flagged."},
+		{[]string{"robots", "all"}, []string{"googlebot: none"}, true, true, true,
+			"All and none should be flagged."},
+	}
+	for _, tc := range tests {
+		var d robotsDirectives
+		if tc.meta != nil {
+			d.addMeta(tc.meta[0], tc.meta[1], DefaultRobotsAgent)
+		}
+		for _, h := range tc.header {
+			d.addHeader(h, DefaultRobotsAgent)
+		}
+		st := StatsNew(testURLRoot, "html", nil)
+		d.apply(st)
+		if st.NoIndex != tc.expectedNoIndex || st.NoFollow != tc.expectedNoFollow {
+			t.Errorf("%s Noindex: %t Nofollow: %t", tc.message, st.NoIndex, st.NoFollow)
+		}
+		if (strings.Join(st.Issues, ",") == RuleRobotsConflict) != tc.expectedConflict {
+			t.Errorf("%s Issues: %v", tc.message, st.Issues)
+		}
+	}
+}
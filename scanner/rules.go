@@ -0,0 +1,127 @@
+package scanner
+
+// SEO rule violation names reported in the summary.
+const (
+	RuleCanonicalMissing = "canonicalMissing" // Page has no canonical link.
+	RuleMetaMissing      = "metaMissing"      // Page has no meta description.
+	RuleMetaMultiple     = "metaMultiple"     // Page has more than one meta description.
+	RuleMetaSize         = "metaSize"         // Meta description is not the proper size.
+	RuleTitleMissing     = "titleMissing"     // Page has no title.
+	RuleTitleMultiple    = "titleMultiple"    // Page has more than one title.
+	RuleTitleSize        = "titleSize"        // Title does not meet size criteria.
+	RuleAltMissing       = "altMissing"       // One or more images are missing alt text.
+	RuleH1Missing        = "h1Missing"        // Page has no h1.
+	RuleH1Multiple       = "h1Multiple"       // Page has more than one h1.
+	RuleTypeMismatch     = "typeMismatch"     // URL was served as a different type than referenced.
+	RuleCharsetMissing   = "charsetMissing"   // Page does not declare its character encoding.
+	RuleCharsetConflict  = "charsetConflict"  // Page declares character encodings that disagree.
+	RuleBodySize         = "bodyTooLarge"     // URL body is larger than the size limit for its type.
+	RuleTimeout          = "timeout"          // URL body was not read in full before the request timed out.
+	RuleRobotsConflict   = "robotsConflict"   // Robots directives contradict each other.
+	RuleSitemapNoIndex   = "sitemapNoindex"   // Page listed in a sitemap is marked noindex.
+
+	RuleCanonicalMultiple = "canonicalMultiple" // Page has more than one canonical link.
+	RuleCanonicalRelative = "canonicalRelative" // Canonical link is a relative URL.
+	RuleCanonicalHost     = "canonicalHost"     // Canonical URL is on a different host.
+	RuleCanonicalMismatch = "canonicalMismatch" // Canonical link and Link header disagree.
+	RuleCanonicalStatus   = "canonicalStatus"   // Canonical URL does not return 200.
+	RuleCanonicalRedirect = "canonicalRedirect" // Canonical URL redirects.
+	RuleCanonicalNoIndex  = "canonicalNoindex"  // Canonical URL is marked noindex.
+	RuleCanonicalChain    = "canonicalChain"    // Canonical URL names another canonical URL.
+
+	RuleTitleDuplicate = "titleDuplicate" // Page shares its title with other pages.
+	RuleMetaDuplicate  = "metaDuplicate"  // Page shares its meta description with other pages.
+	RuleH1Duplicate    = "h1Duplicate"    // Page shares its h1 with other pages.
+
+	RuleHeadingEmpty   = "headingEmpty"   // Page has a heading with no text.
+	RuleHeadingSkipped = "headingSkipped" // Page skips a heading level ex: h1 => h3.
+	RuleH1Title        = "h1SameAsTitle"  // Page h1 is the same as the title.
+
+	RuleOGMissing      = "ogMissing"      // Page is missing a required Open Graph property.
+	RuleOGSize         = "ogSize"         // Open Graph title or description is too long.
+	RuleOGURLMismatch  = "ogURLMismatch"  // Open Graph URL is not the canonical URL.
+	RuleTwitterMissing = "twitterMissing" // Page is missing a required Twitter Card tag.
+	RuleTwitterCard    = "twitterCard"    // Twitter Card type is not valid.
+	RuleTwitterSize    = "twitterSize"    // Twitter Card title or description is too long.
+
+	RuleStructuredSyntax  = "structuredDataSyntax"  // A JSON-LD block is not valid JSON.
+	RuleStructuredMissing = "structuredDataMissing" // A structured data item is missing required properties.
+
+	RuleHreflangInvalid   = "hreflangInvalid"   // An hreflang is not a valid language or region code.
+	RuleHreflangSelf      = "hreflangNoSelf"    // Page has hreflang annotations but none for itself.
+	RuleHreflangDefault   = "hreflangXDefault"  // Page has more than one x-default annotation.
+	RuleHreflangConflict  = "hreflangConflict"  // An hreflang names more than one URL.
+	RuleHreflangStatus    = "hreflangStatus"    // An hreflang target does not return 200.
+	RuleHreflangCanonical = "hreflangCanonical" // An hreflang target canonicalizes elsewhere.
+	RuleHreflangReturn    = "hreflangNoReturn"  // An hreflang target does not link back.
+
+	RuleFragmentBroken = "fragmentBroken" // Page links to an anchor that doesn't exist.
+
+	RuleAnchorGeneric    = "anchorGeneric"    // A link text says nothing about its target ex: click here.
+	RuleAnchorEmpty      = "anchorEmpty"      // A link has no text, image alt text or aria-label.
+	RuleNofollowInternal = "nofollowInternal" // An internal link is marked nofollow.
+	RuleBlankNoopener    = "blankNoopener"    // A link opens a new window without rel="noopener".
+
+	RuleMixedActive  = "mixedContentActive"  // An https page loads scripts, styles, frames or fonts over http.
+	RuleMixedPassive = "mixedContentPassive" // An https page loads images or media over http.
+	RuleLinkInsecure = "insecureLink"        // An https page links to an internal http page.
+	RuleFormInsecure = "insecureForm"        // An https page has a form posting to http.
+
+	RuleHSTSMissing        = "hstsMissing"              // An https page has no Strict-Transport-Security header.
+	RuleHSTSWeak           = "hstsWeak"                 // HSTS max-age is under a year.
+	RuleCSPMissing         = "cspMissing"               // Page has no Content-Security-Policy header.
+	RuleCSPWeak            = "cspWeak"                  // The Content-Security-Policy doesn't restrict scripts.
+	RuleNoSniffMissing     = "noSniffMissing"           // Page has no X-Content-Type-Options: nosniff header.
+	RuleFrameMissing       = "frameOptionsMissing"      // Page has no X-Frame-Options or CSP frame-ancestors.
+	RuleFrameWeak          = "frameOptionsWeak"         // X-Frame-Options is not DENY or SAMEORIGIN.
+	RuleReferrerMissing    = "referrerPolicyMissing"    // Page has no Referrer-Policy header.
+	RuleReferrerWeak       = "referrerPolicyWeak"       // The Referrer-Policy sends full URLs to other sites.
+	RulePermissionsMissing = "permissionsPolicyMissing" // Page has no Permissions-Policy header.
+)
+
+// Violations returns the names of the SEO rules this scan result breaks. Issues found
+// while scanning are always included. The page rules only apply to html pages that were
+// successfully loaded and may be indexed.
+func (s *Stats) Violations() []string {
+	v := []string{}
+	v = append(v, s.Issues...)
+	if !s.isPage() || s.StatusCode < 200 || s.StatusCode > 299 || s.NoIndex {
+		return v
+	}
+	if !s.Canonical {
+		v = append(v, RuleCanonicalMissing)
+	}
+	switch {
+	case s.MetaCount == 0:
+		v = append(v, RuleMetaMissing)
+	case s.MetaCount > 1:
+		v = append(v, RuleMetaMultiple)
+	}
+	if s.MetaSizedErr {
+		v = append(v, RuleMetaSize)
+	}
+	switch {
+	case s.TitleCount == 0:
+		v = append(v, RuleTitleMissing)
+	case s.TitleCount > 1:
+		v = append(v, RuleTitleMultiple)
+	}
+	if s.TitleSizedErr {
+		v = append(v, RuleTitleSize)
+	}
+	if s.AltTagsErr {
+		v = append(v, RuleAltMissing)
+	}
+	switch {
+	case s.H1Count == 0:
+		v = append(v, RuleH1Missing)
+	case s.H1Count > 1:
+		v = append(v, RuleH1Multiple)
+	}
+	v = append(v, s.headingViolations()...)
+	v = append(v, s.socialViolations()...)
+	v = append(v, s.structuredViolations()...)
+	v = append(v, s.hreflangViolations()...)
+	v = append(v, s.linkViolations()...)
+	return v
+}
@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var (
+	testRulesViolations = []struct {
+		urlType    string
+		status     int
+		canonical  bool
+		metaCount  int
+		metaErr    bool
+		titleCount int
+		titleErr   bool
+		altErr     bool
+		h1Count    int
+		expected   []string
+		message    string
+	}{
+		{"html", 200, true, 1, false, 1, false, false, 1, []string{},
+			"Valid page should not report violations."},
+		{"html", 200, false, 0, false, 0, false, true, 0,
+			[]string{RuleCanonicalMissing, RuleMetaMissing, RuleTitleMissing, RuleAltMissing, RuleH1Missing},
+			"Missing elements should have been reported."},
+		{"html", 200, true, 2, true, 2, true, false, 2,
+			[]string{RuleMetaMultiple, RuleMetaSize, RuleTitleMultiple, RuleTitleSize, RuleH1Multiple},
+			"Multiple and badly sized elements should have been reported."},
+		{"html", 404, false, 0, false, 0, false, false, 0, []string{},
+			"Pages not loaded should not report violations."},
+		{"img", 200, false, 0, false, 0, false, false, 0, []string{},
+			"Assets should not report violations."},
+	}
+)
+
+func TestStatsViolations(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/faq")
+	for _, tc := range testRulesViolations {
+		st := StatsNew(u, tc.urlType, nil)
+		st.StatusCode = tc.status
+		st.Canonical = tc.canonical
+		st.MetaCount = tc.metaCount
+		st.MetaSizedErr = tc.metaErr
+		st.TitleCount = tc.titleCount
+		st.TitleSizedErr = tc.titleErr
+		st.AltTagsErr = tc.altErr
+		st.H1Count = tc.h1Count
+		testSocialTags(st)
+		if v := st.Violations(); !reflect.DeepEqual(v, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, v)
+		}
+	}
+}
+
+func TestStatsViolationsNoIndex(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/faq")
+	st := StatsNew(u, "html", nil)
+	st.StatusCode = 200
+	st.NoIndex = true
+	st.addIssue(RuleRobotsConflict)
+	if v := st.Violations(); !reflect.DeepEqual(v, []string{RuleRobotsConflict}) {
+		t.Errorf("Noindex pages should only report issues. Received: %v", v)
+	}
+}
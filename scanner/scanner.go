@@ -1,54 +1,95 @@
 package scanner
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/composer22/pzscan/logger"
 )
 
 const (
-	maxJobs = 10000 // The jobq maximum number of jobs to hold. We need something for non blocking.
+	maxJobs       = 10000 // The jobq maximum number of jobs to hold. We need something for non blocking.
+	maxQueuedList = 100   // The maximum number of queued URLs listed when the scanner ends.
 )
 
 var (
-	maxIdleDuration = 15 * time.Second       // How long we should wait on empty queues before auto quitting.
-	maxScannerSleep = 100 * time.Millisecond // How long should the scanner sleep before checking for results.
+	maxIdleDuration  = 15 * time.Second       // How long we should wait on empty queues before auto quitting.
+	maxScannerSleep  = 100 * time.Millisecond // How long should the scanner sleep before checking for results.
+	maxDrainDuration = 10 * time.Second       // How long we should wait on jobs in progress when stopping.
 )
 
 // Scanner is a manager of scanning jobs and evaluates the results of the workers.
 type Scanner struct {
-	RootURL    *url.URL                     // The original URL that we started the scan from.
-	Tests      map[string]map[string]*Stats // URL test results go in here.
-	MaxRunMin  int                          // The Maximum number of minutes we want the scanner to run.
-	MaxWorkers int                          // The maximumm job workers we want in the pool.
-	StartTime  time.Time                    // When the scanner started runnning.
-	ExpireTime time.Time                    // The expire time: when the scanner should stop running.
-	EndTime    time.Time                    // When the scanner ended.
-	mu         sync.Mutex                   // For locking access.
-	wg         sync.WaitGroup               // Synchronize close() of job channel.
-	stopOnce   sync.Once                    // Used to close down the system once and once only.
-	log        *logger.Logger               // Logger for writing final results.
-	jobq       chan *scanJob                // Channel to send jobs.
-	doneCh     chan *scanJob                // Channel to receive done jobs.
+	RootURL         *url.URL                // The original URL that we started the scan from.
+	Store           Store                   // URL test results and the crawl frontier go in here.
+	History         *History                // Optional results of previous runs for incremental scans.
+	MaxRunMin       int                     // The Maximum number of minutes we want the scanner to run.
+	MaxWorkers      int                     // The maximumm job workers we want in the pool.
+	StartTime       time.Time               // When the scanner started runnning.
+	ExpireTime      time.Time               // The expire time: when the scanner should stop running.
+	EndTime         time.Time               // When the scanner ended.
+	StopReason      string                  // Why the scanner ended ex: idle, expired, limit, signal.
+	ReportFile      string                  // Optional file to write the json report to.
+	Queued          []string                // URLs still waiting to be scanned when the scanner ended.
+	QueuedCount     int                     // How many URLs were still waiting to be scanned.
+	Requests        int                     // The number of URL scans completed, including resumed runs.
+	CheckpointFile  string                  // Optional file to periodically save the state of the scan to.
+	CheckpointSec   int                     // How often, in seconds, the checkpoint file is written.
+	MaxBodySize     SizeLimits              // The maximum number of body bytes read for each URL type.
+	TimeoutSec      int                     // How long, in seconds, a request may take, including its body.
+	RobotsAgent     string                  // The bot whose robots directives apply as well as the generic ones.
+	RespectNofollow bool                    // Should links on pages marked nofollow be left unscanned?
+	NearDuplicates  bool                    // Should near identical titles, descriptions and h1s be grouped?
+	GraphFile       string                  // Optional file to export the link graph to, as DOT or GraphML.
+	GraphPagesOnly  bool                    // Should only internal html pages be exported?
+	GraphByDir      bool                    // Should the exported URLs be collapsed into their directories?
+	GraphColor      bool                    // Should exported nodes be coloured by status?
+	mu              sync.Mutex              // For locking access.
+	wg              sync.WaitGroup          // Synchronize close() of job channel.
+	stopOnce        sync.Once               // Used to close down the system once and once only.
+	log             *logger.Logger          // Logger for writing final results.
+	jobq            chan *scanJob           // Channel to send jobs.
+	doneCh          chan *scanJob           // Channel to receive done jobs.
+	sigCh           chan os.Signal          // Channel to receive operating system signals.
+	ctx             context.Context         // Context for cancelling requests in progress.
+	cancel          context.CancelFunc      // Cancels requests in progress.
+	pending         map[*scanJob]bool       // Jobs sent to the workers that have no result yet.
+	resumed         bool                    // Was the state of the scan loaded from a checkpoint?
+	sitemap         map[string]bool         // URLs listed in the sitemaps of the site.
+	sitemapHreflang map[string][]*Alternate // The hreflang annotations of the sitemap URLs.
 }
 
 // New is a factory function that creates a new Scanner instance.
 func New(hostname string, maxRunMin int, maxWorkers int) *Scanner {
 	u, _ := url.Parse(fmt.Sprintf("http://%s", hostname))
+	ctx, cancel := context.WithCancel(context.Background())
+	maxBodySize, _ := ParseSizeLimits(DefaultMaxBodySize)
 	return &Scanner{
-		RootURL:    u,
-		Tests:      make(map[string]map[string]*Stats),
-		MaxRunMin:  maxRunMin,
-		MaxWorkers: maxWorkers,
-		log:        logger.New(logger.UseDefault, false),
-		jobq:       make(chan *scanJob, maxJobs),
-		doneCh:     make(chan *scanJob, maxJobs),
+		RootURL:       u,
+		Store:         memoryStoreNew(),
+		MaxRunMin:     maxRunMin,
+		MaxWorkers:    maxWorkers,
+		Queued:        []string{},
+		CheckpointSec: DefaultCheckpointSec,
+		MaxBodySize:   maxBodySize,
+		TimeoutSec:    DefaultTimeoutSec,
+		RobotsAgent:   DefaultRobotsAgent,
+		log:           logger.New(logger.UseDefault, false),
+		jobq:          make(chan *scanJob, maxJobs),
+		doneCh:        make(chan *scanJob, maxJobs),
+		sigCh:         make(chan os.Signal, 1),
+		ctx:           ctx,
+		cancel:        cancel,
+		pending:       make(map[*scanJob]bool),
 	}
 }
 
@@ -62,119 +103,342 @@ func PrintVersionAndExit() {
 func (s *Scanner) Run() {
 	// Trap all signals to quit.
 	s.handleSignals()
+	defer signal.Stop(s.sigCh)
+	defer s.report()
 
 	s.mu.Lock()
 
 	// Spin up the workers
 	for i := 0; i < s.MaxWorkers; i++ {
 		s.wg.Add(1)
-		go scanWorker(s.jobq, s.doneCh, &s.wg)
+		go scanWorker(s.ctx, s.jobq, s.doneCh, &s.wg, workerOptions{
+			maxBodySize: s.MaxBodySize,
+			timeout:     time.Duration(s.TimeoutSec) * time.Second,
+			robotsAgent: s.RobotsAgent,
+		})
 	}
 
 	s.StartTime = time.Now()
 	s.ExpireTime = s.StartTime.Add(time.Duration(s.MaxRunMin) * time.Minute)
 	s.mu.Unlock()
 
+	if !s.resumed {
+		if err := s.Store.Reset(); err != nil {
+			s.log.Errorf("Unable to reset store: %s", err)
+		}
+		p, _ := url.Parse(fmt.Sprintf("http://%s", ""))
+		s.queue(scanJobNew(s.RootURL, "html", p)) // Create first job.  Assume its a page.
+	}
+
+	// Pages listed in the sitemaps are checked against their robots directives. A signal
+	// while they load cancels the requests and stops the scan.
+	loaded := make(chan struct{})
+	go func() {
+		s.loadSitemaps(&http.Client{Timeout: time.Duration(s.TimeoutSec) * time.Second})
+		close(loaded)
+	}()
+	select {
+	case <-loaded:
+	case sig := <-s.sigCh:
+		s.log.Noticef("Received %s, stopping scan.", sig)
+		s.StopReason = StopSignal
+		s.cancel()
+		<-loaded
+		s.Stop()
+		return
+	}
+
 	// Main event loop.
-	p, _ := url.Parse(fmt.Sprintf("http://%s", ""))
-	s.jobq <- scanJobNew(s.RootURL, "html", p) // Create first job.  Assume its a page.
+	var idleTime time.Time
+	checkpointTime := time.Now()
 	for {
+		s.dispatch()
 		select {
 		case j, ok := <-s.doneCh:
 			if !ok {
 				s.Stop()
 				return
 			}
+			idleTime = time.Time{}
 			s.evaluate(j)
+			// Frontier overflowed?
+			if s.StopReason == StopLimit {
+				s.Stop()
+				return
+			}
+		case sig := <-s.sigCh:
+			s.log.Noticef("Received %s, stopping scan.", sig)
+			s.StopReason = StopSignal
+			s.Stop()
+			return
 		default:
 			// Drop dead time reached?
 			if time.Now().After(s.ExpireTime) {
+				s.StopReason = StopExpired
 				s.Stop()
 				return
 			}
 			// Test a reasonable time for all jobs to be cleared, and then die if no more
-			// jobs need to be done or are returned.
-			if len(s.jobq) == 0 && len(s.doneCh) == 0 {
-				time.Sleep(maxIdleDuration)
-				if len(s.doneCh) == 0 {
+			// jobs need to be done, are in progress or are returned.
+			if len(s.jobq) == 0 && len(s.doneCh) == 0 && len(s.pending) == 0 &&
+				s.Store.JobLen() == 0 {
+				if idleTime.IsZero() {
+					idleTime = time.Now()
+				} else if time.Since(idleTime) > maxIdleDuration {
+					s.StopReason = StopIdle
 					s.Stop()
 					return
 				}
+			} else {
+				idleTime = time.Time{}
+			}
+			// Save the state of the scan?
+			if s.CheckpointFile != "" &&
+				time.Since(checkpointTime) > time.Duration(s.CheckpointSec)*time.Second {
+				s.saveCheckpoint()
+				checkpointTime = time.Now()
 			}
 			time.Sleep(maxScannerSleep) // Sleep a while.
 		}
 	}
 }
 
-// Stop performs close out procedures.
+// Stop performs close out procedures. No new jobs are issued: jobs in the frontier or not
+// yet picked up by a worker are recorded as queued. Jobs already in progress are given
+// until the drain deadline to finish before they are cancelled. Their results are kept,
+// and the links found on them are recorded as queued. Cancelled jobs are also recorded as
+// queued.
 func (s *Scanner) Stop() {
 	s.stopOnce.Do(func() {
 		s.EndTime = time.Now()
-		close(s.doneCh)
 		close(s.jobq)
-		s.wg.Wait()
+		for _ = range s.jobq {
+		}
+
+		drained := make(chan struct{})
+		go func() {
+			s.wg.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-time.After(maxDrainDuration):
+			s.log.Warningf("Workers did not finish in %s, cancelling requests.", maxDrainDuration)
+			s.cancel()
+			<-drained
+		}
+		s.cancel()
+
+		close(s.doneCh)
+		for j := range s.doneCh {
+			s.evaluate(j)
+		}
+
+		s.QueuedCount = len(s.pending) + s.Store.JobLen()
+		for _, j := range s.pendingJobs() {
+			s.Queued = append(s.Queued, j.Stat.URL.String())
+		}
+		err := s.Store.EachJob(func(j *scanJob) error {
+			if len(s.Queued) >= maxQueuedList {
+				return errStopEach
+			}
+			s.Queued = append(s.Queued, j.Stat.URL.String())
+			return nil
+		})
+		if err != nil && err != errStopEach {
+			s.log.Errorf("Unable to read frontier: %s", err)
+		}
+		if len(s.Queued) > maxQueuedList {
+			s.Queued = s.Queued[:maxQueuedList]
+		}
+		if s.CheckpointFile != "" {
+			s.saveCheckpoint()
+		}
 	})
 }
 
-// handleSignals responds to operating system interrupts such as application kills.
-func (s *Scanner) handleSignals() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	go func() {
-		for _ = range c {
-			s.Stop()
-			os.Exit(0)
+// report prints a summary of the scan to the log and as text, and writes the report and
+// graph files if they were requested.
+func (s *Scanner) report() {
+	sum := s.Summarize()
+	s.log.Infof(fmt.Sprint(sum))
+	fmt.Print(sum.Text())
+	if s.ReportFile != "" {
+		if err := s.writeReport(s.ReportFile, sum); err != nil {
+			s.log.Errorf("Unable to write report %s: %s", s.ReportFile, err)
 		}
-	}()
+	}
+	if s.GraphFile != "" {
+		if err := s.writeGraph(s.GraphFile); err != nil {
+			s.log.Errorf("Unable to write graph %s: %s", s.GraphFile, err)
+		}
+	}
 }
 
-// evaluate examines the result of the job and launches new jobs if site children are found.
-func (s *Scanner) evaluate(job *scanJob) {
+// handleSignals traps operating system interrupts such as application kills so the
+// event loop can shut down gracefully.
+func (s *Scanner) handleSignals() {
+	signal.Notify(s.sigCh, os.Interrupt, syscall.SIGTERM)
+}
+
+// record stores the result of a job, together with the new jobs found on it, and prints
+// it to the log. If the jobs cannot be stored the scanner is flagged to stop.
+func (s *Scanner) record(job *scanJob, jobs []*scanJob) {
+	delete(s.pending, job)
+	s.Requests++
 	pURL := job.Stat.ParentURL.String()
 	cURL := job.Stat.URL.String()
-	// Initialize result slot
-	if _, ok := s.Tests[cURL]; !ok {
-		s.Tests[cURL] = make(map[string]*Stats)
-	}
 
 	// Store the result of this scan and print it to the log.
-	if _, ok := s.Tests[cURL][pURL]; !ok {
-		s.Tests[cURL][pURL] = job.Stat
+	st := job.Stat
+	if s.scanned(cURL, pURL) {
+		st = nil
+	}
+	if st != nil || len(jobs) > 0 {
+		if err := s.Store.PutPage(st, jobs); err != nil {
+			s.log.Errorf("Unable to store result for %s: %s", cURL, err)
+			if len(jobs) > 0 {
+				s.StopReason = StopLimit
+			}
+		}
+	}
+	if st != nil {
 		s.log.Infof(fmt.Sprint(job.Stat))
 	}
-	// Check for any URL's returned and create new jobs.
-	for _, c := range job.Children {
-		// No Scheme?  Assume http:
-		if c.URL.Scheme == "" {
-			c.URL.Scheme = "http"
+	s.remember(job)
+}
+
+// recall attaches what previous runs learned about a URL to a job so the worker can ask
+// the server if it changed.
+func (s *Scanner) recall(j *scanJob) {
+	if s.History == nil {
+		return
+	}
+	e, err := s.History.entry(j.Stat.URL.String())
+	if err != nil {
+		s.log.Errorf("Unable to read history for %s: %s", j.Stat.URL, err)
+		return
+	}
+	if e != nil && e.Stat != nil && (e.Stat.ETag != "" || e.Stat.LastModified != "") {
+		j.Prev = e
+	}
+}
+
+// remember saves the result of a downloaded URL so later runs can reuse it if the URL
+// has not been modified.
+func (s *Scanner) remember(j *scanJob) {
+	if s.History == nil || j.Stat.Reused || j.Stat.StatusCode != http.StatusOK ||
+		(j.Stat.ETag == "" && j.Stat.LastModified == "") {
+		return
+	}
+	e := &historyEntry{Stat: j.Stat, Children: j.Children}
+	if err := s.History.putEntry(j.Stat.URL.String(), e); err != nil {
+		s.log.Errorf("Unable to save history for %s: %s", j.Stat.URL, err)
+	}
+}
+
+// scanned returns true if a result is stored for a URL found on a parent page.
+func (s *Scanner) scanned(u string, p string) bool {
+	st, err := s.Store.Result(u, p)
+	if err != nil {
+		s.log.Errorf("Unable to read result for %s: %s", u, err)
+	}
+	return st != nil
+}
+
+// scannedAny returns true if a result is stored for a URL found on any page.
+func (s *Scanner) scannedAny(u string) bool {
+	parents, err := s.Store.Results(u)
+	if err != nil {
+		s.log.Errorf("Unable to read results for %s: %s", u, err)
+	}
+	return len(parents) > 0
+}
+
+// queue adds a new job to the frontier. If the frontier cannot hold the job the scanner
+// is flagged to stop.
+func (s *Scanner) queue(j *scanJob) {
+	if err := s.Store.PushJob(j); err != nil {
+		s.log.Errorf("Unable to queue %s: %s", j.Stat.URL, err)
+		s.StopReason = StopLimit
+	}
+}
+
+// dispatch moves jobs from the frontier to the workers while there is room for them.
+// Only a few jobs are handed out at a time so the frontier holds the bulk of the work.
+func (s *Scanner) dispatch() {
+	for len(s.jobq) < s.MaxWorkers {
+		j, err := s.Store.PopJob()
+		if err != nil {
+			s.log.Errorf("Unable to read frontier: %s", err)
+			s.StopReason = StopLimit
+			return
+		}
+		if j == nil {
+			return
 		}
-		// No host?  Assume its us.
-		if c.URL.Host == "" {
-			c.URL.Host = s.RootURL.Host
+		s.pending[j] = true
+		s.recall(j)
+		s.jobq <- j
+	}
+}
+
+// pendingJobs returns the jobs that have no result yet, ordered by URL.
+func (s *Scanner) pendingJobs() []*scanJob {
+	jobs := make([]*scanJob, 0, len(s.pending))
+	for j := range s.pending {
+		jobs = append(jobs, j)
+	}
+	sort.Sort(jobsByURL(jobs))
+	return jobs
+}
+
+// evaluate examines the result of the job and launches new jobs if site children are found.
+func (s *Scanner) evaluate(job *scanJob) {
+	// Resolve relative URL's against the URL the page was served from, so each child
+	// has the same key however the page referred to it.
+	base := job.Stat.pageURL()
+	for _, c := range job.Children {
+		if base != nil {
+			c.URL = base.ResolveReference(c.URL)
 		}
-		switch c.URLType {
-		case "html":
+		// Fragments point within the page, so the page is only scanned once.
+		c.URL.Fragment = ""
+		c.URL.RawFragment = ""
+	}
+	s.mixedContent(job)
+	cURL := job.Stat.URL.String()
+	// Check for any URL's returned and create new jobs.
+	jobs := []*scanJob{}
+	for _, c := range job.Children {
+		switch {
+		case isPageType(c.URLType):
 			// Don't scan foreign pages.
 			if !strings.Contains(c.URL.Host, s.RootURL.Host) {
 				continue
 			}
+			// Don't follow links the page asks robots not to follow.
+			if job.Stat.NoFollow && s.RespectNofollow {
+				continue
+			}
 			// If we haven't scanned this url, do it. [new][sourcepage]
-			if _, ok := s.Tests[c.URL.String()][cURL]; !ok {
-				s.jobq <- scanJobNew(c.URL, c.URLType, job.Stat.URL)
+			if !s.scanned(c.URL.String(), cURL) {
+				jobs = append(jobs, scanJobNew(c.URL, c.URLType, job.Stat.URL))
 			}
 		default:
 			// If it is a site asset
 			if strings.Contains(c.URL.Host, s.RootURL.Host) {
 				// If we haven't scanned this asset, do it.
-				if _, ok := s.Tests[c.URL.String()]; !ok {
-					s.jobq <- scanJobNew(c.URL, c.URLType, job.Stat.URL)
+				if !s.scannedAny(c.URL.String()) {
+					jobs = append(jobs, scanJobNew(c.URL, c.URLType, job.Stat.URL))
 				}
 			} else { // Foreign asset
 				// If we haven't scanned this url, do it. [new][sourcepage]
-				if _, ok := s.Tests[c.URL.String()][cURL]; !ok {
-					s.jobq <- scanJobNew(c.URL, c.URLType, job.Stat.URL)
+				if !s.scanned(c.URL.String(), cURL) {
+					jobs = append(jobs, scanJobNew(c.URL, c.URLType, job.Stat.URL))
 				}
 			}
 		}
 	}
+	s.record(job, jobs)
 }
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -78,8 +79,11 @@ func TestScanNew(t *testing.T) {
 	if s.RootURL.String() != tURL.String() {
 		t.Errorf("RootURL not initialized.")
 	}
-	if len(s.Tests) != 0 {
-		t.Errorf("Tests not initialized.")
+	if s.Store == nil || s.Store.ResultLen() != 0 || s.Store.JobLen() != 0 {
+		t.Errorf("Store not initialized.")
+	}
+	if s.Store.Persistent() {
+		t.Errorf("Default store should be in memory.")
 	}
 	if s.MaxRunMin != testMaxRunMin {
 		t.Errorf("MaxRunMin not initialized.")
@@ -98,6 +102,12 @@ func TestScanNew(t *testing.T) {
 	if s.EndTime != tTimeEmpty {
 		t.Errorf("EndTime not initialized.")
 	}
+	if s.StopReason != "" {
+		t.Errorf("StopReason not initialized.")
+	}
+	if len(s.Queued) != 0 {
+		t.Errorf("Queued not initialized.")
+	}
 	if fmt.Sprint(reflect.TypeOf(s.mu)) != "sync.Mutex" {
 		t.Errorf("sync.Mutex not initialized.")
 	}
@@ -141,7 +151,7 @@ func TestScanRun(t *testing.T) {
 	scnr.Run()
 	srvr.Close()
 
-	for _, chdrn := range scnr.Tests {
+	scnr.Store.EachResult(func(u string, chdrn map[string]*Stats) error {
 		for _, stat := range chdrn {
 			k := stat.URLType
 			if k == "html" && stat.URL.Path == "/page2" {
@@ -176,8 +186,12 @@ func TestScanRun(t *testing.T) {
 				t.Errorf("Status Code returned tested incorrectly.")
 			}
 		}
-	}
+		return nil
+	})
 	scnr.Stop()
+	if scnr.StopReason != StopIdle {
+		t.Errorf("Scanner should have stopped when idle.")
+	}
 }
 
 func TestScanVersionAndExit(t *testing.T) {
@@ -190,12 +204,91 @@ func TestScanStop(t *testing.T) {
 	t.Skip("Covered by TestScanRun")
 }
 
+func TestScanSignalDuringSitemaps(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+	u, _ := url.Parse(srvr.URL)
+	scnr := New(u.Host, testMaxRunMin, testMaxWorkers)
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		scnr.sigCh <- syscall.SIGTERM
+	}()
+	start := time.Now()
+	scnr.Run()
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Signals should stop the scan while sitemaps load.")
+	}
+	if scnr.StopReason != StopSignal || scnr.QueuedCount != 1 {
+		t.Errorf("Scan should have stopped on a signal with the root queued: %s %d",
+			scnr.StopReason, scnr.QueuedCount)
+	}
+}
+
 func TestScanHandleSignals(t *testing.T) {
 	t.Parallel()
-	t.Skip("Cannot test due to Exit()")
+	hPage := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<a href="/next">next</a>`)
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(hPage))
+	defer srvr.Close()
+	u, _ := url.Parse(srvr.URL)
+	scnr := New(u.Host, testMaxRunMin, testMaxWorkers)
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		scnr.sigCh <- syscall.SIGTERM
+	}()
+	start := time.Now()
+	scnr.Run()
+	if time.Since(start) > maxIdleDuration {
+		t.Errorf("Scanner should have stopped before becoming idle.")
+	}
+	if scnr.StopReason != StopSignal {
+		t.Errorf("Scanner should have stopped on a signal.")
+	}
+	if scnr.Store.ResultLen() == 0 {
+		t.Errorf("Results should have been kept.")
+	}
+	if !scnr.Summarize().Partial {
+		t.Errorf("Summary should have been marked partial.")
+	}
 }
 
 func TestScanEvaluate(t *testing.T) {
 	t.Parallel()
 	t.Skip("Covered by TestScanRun")
 }
+
+func TestScanEvaluateNofollow(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		nofollow bool
+		respect  bool
+		expected int
+		message  string
+	}{
+		{false, true, 2, "Links on followed pages should have been queued."},
+		{true, false, 2, "Nofollow should be ignored unless respected."},
+		{true, true, 1, "Only assets should be queued from nofollow pages."},
+	}
+	for _, tc := range tests {
+		s := New("example.com", testMaxRunMin, testMaxWorkers)
+		s.RespectNofollow = tc.respect
+		j := scanJobNew(s.RootURL, "html", s.RootURL)
+		j.Stat.NoFollow = tc.nofollow
+		page, _ := url.Parse("/page")
+		img, _ := url.Parse("/logo.png")
+		j.Children = append(j.Children, &scanJobChild{URL: page, URLType: "html"},
+			&scanJobChild{URL: img, URLType: "img"})
+		s.evaluate(j)
+		if n := s.Store.JobLen(); n != tc.expected {
+			t.Errorf("%s Expected %d jobs, received %d.", tc.message, tc.expected, n)
+		}
+	}
+}
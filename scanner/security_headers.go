@@ -0,0 +1,240 @@
+package scanner
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	hstsMinAge           = 31536000 // The least HSTS max-age, in seconds, that isn't weak.
+	summaryMaxHeaderList = 10       // The number of hosts and paths listed for a header.
+)
+
+// securityHeaders are the security headers audited on html responses, in report order.
+var securityHeaders = []string{
+	"Strict-Transport-Security",
+	"Content-Security-Policy",
+	"X-Content-Type-Options",
+	"X-Frame-Options",
+	"Referrer-Policy",
+	"Permissions-Policy",
+}
+
+// weakReferrerPolicies send the full URL to other sites.
+var weakReferrerPolicies = map[string]bool{
+	"unsafe-url":                 true,
+	"no-referrer-when-downgrade": true,
+}
+
+// HeaderCoverage is where the pages of a site lack a security header.
+type HeaderCoverage struct {
+	Header  string       `json:"header"`  // The security header.
+	Missing int          `json:"missing"` // Pages without the header.
+	Hosts   []string     `json:"hosts"`   // The hosts with pages without the header.
+	Paths   []*PathCount `json:"paths"`   // The directories with the most pages without the header.
+}
+
+// PathCount is a directory and how many of its pages lack a header.
+type PathCount struct {
+	Path  string `json:"path"`  // The directory ex: http://example.com/blog/.
+	Count int    `json:"count"` // Pages in the directory without the header.
+}
+
+// cspDirectives parses a Content-Security-Policy into its directives and their sources.
+// The first occurrence of a directive wins.
+func cspDirectives(policy string) map[string][]string {
+	d := make(map[string][]string)
+	for _, directive := range strings.Split(policy, ";") {
+		f := strings.Fields(directive)
+		if len(f) == 0 {
+			continue
+		}
+		name := strings.ToLower(f[0])
+		if _, ok := d[name]; !ok {
+			d[name] = f[1:]
+		}
+	}
+	return d
+}
+
+// cspWeak returns true if a policy doesn't restrict scripts: there is no script-src or
+// default-src, or it allows inline scripts, eval or any host.
+func cspWeak(d map[string][]string) bool {
+	sources, ok := d["script-src"]
+	if !ok {
+		sources, ok = d["default-src"]
+	}
+	if !ok {
+		return true
+	}
+	var nonce bool
+	for _, s := range sources {
+		s = strings.ToLower(s)
+		nonce = nonce || strings.HasPrefix(s, "'nonce-") || strings.HasPrefix(s, "'sha")
+	}
+	for _, s := range sources {
+		switch strings.ToLower(s) {
+		case "'unsafe-eval'", "*", "http:", "https:", "data:":
+			return true
+		case "'unsafe-inline'":
+			// Browsers ignore unsafe-inline when a nonce or hash is given.
+			if !nonce {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hstsMaxAge returns the max-age of a Strict-Transport-Security header, or -1 if it has
+// none.
+func hstsMaxAge(v string) int {
+	for _, d := range strings.Split(v, ";") {
+		kv := strings.SplitN(strings.TrimSpace(d), "=", 2)
+		if len(kv) == 2 && strings.EqualFold(strings.TrimSpace(kv[0]), "max-age") {
+			n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(kv[1]), `"`))
+			if err == nil {
+				return n
+			}
+		}
+	}
+	return -1
+}
+
+// referrerPolicy returns the policy a browser applies: the last one it knows of in the
+// comma separated list.
+func referrerPolicy(v string) string {
+	var policy string
+	for _, p := range strings.Split(v, ",") {
+		switch p = strings.ToLower(strings.TrimSpace(p)); p {
+		case "no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+			"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url":
+			policy = p
+		}
+	}
+	return policy
+}
+
+// frameProtected returns true if a page can't be framed by other sites, by an
+// X-Frame-Options header or a CSP frame-ancestors directive.
+func frameProtected(headers map[string]string) bool {
+	if _, ok := cspDirectives(headers["Content-Security-Policy"])["frame-ancestors"]; ok {
+		return true
+	}
+	_, ok := headers["X-Frame-Options"]
+	return ok
+}
+
+// headerMissing returns true if a page lacks a security header. HSTS only applies to
+// pages served over https.
+func (s *Stats) headerMissing(header string) bool {
+	switch header {
+	case "Strict-Transport-Security":
+		if s.pageURL().Scheme != "https" {
+			return false
+		}
+	case "X-Frame-Options":
+		return !frameProtected(s.SecurityHeaders)
+	}
+	_, ok := s.SecurityHeaders[header]
+	return !ok
+}
+
+// auditSecurityHeaders records the security headers of an html response and flags the
+// ones that are missing or weak.
+func auditSecurityHeaders(j *scanJob, h http.Header) {
+	st := j.Stat
+	for _, name := range securityHeaders {
+		if v := h[name]; len(v) > 0 {
+			st.SecurityHeaders[name] = strings.Join(v, ", ")
+		}
+	}
+	// Browsers ignore HSTS sent over http, so its strength only matters over https.
+	if st.headerMissing("Strict-Transport-Security") {
+		st.addIssue(RuleHSTSMissing)
+	} else if v, ok := st.SecurityHeaders["Strict-Transport-Security"]; ok &&
+		st.pageURL().Scheme == "https" && hstsMaxAge(v) < hstsMinAge {
+		st.addIssue(RuleHSTSWeak)
+	}
+	if st.headerMissing("Content-Security-Policy") {
+		st.addIssue(RuleCSPMissing)
+	} else if cspWeak(cspDirectives(st.SecurityHeaders["Content-Security-Policy"])) {
+		st.addIssue(RuleCSPWeak)
+	}
+	if !strings.EqualFold(strings.TrimSpace(st.SecurityHeaders["X-Content-Type-Options"]), "nosniff") {
+		st.addIssue(RuleNoSniffMissing)
+	}
+	if st.headerMissing("X-Frame-Options") {
+		st.addIssue(RuleFrameMissing)
+	} else if v, ok := st.SecurityHeaders["X-Frame-Options"]; ok {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "DENY" && v != "SAMEORIGIN" {
+			st.addIssue(RuleFrameWeak)
+		}
+	}
+	if st.headerMissing("Referrer-Policy") {
+		st.addIssue(RuleReferrerMissing)
+	} else if p := referrerPolicy(st.SecurityHeaders["Referrer-Policy"]); p == "" || weakReferrerPolicies[p] {
+		st.addIssue(RuleReferrerWeak)
+	}
+	if st.headerMissing("Permissions-Policy") {
+		st.addIssue(RulePermissionsMissing)
+	}
+}
+
+// headerCounter counts the pages without each security header by host and directory.
+type headerCounter map[string]map[string]int
+
+// add counts the security headers an audited page lacks.
+func (c headerCounter) add(st *Stats) {
+	for _, h := range securityHeaders {
+		if !st.headerMissing(h) {
+			continue
+		}
+		if c[h] == nil {
+			c[h] = make(map[string]int)
+		}
+		c[h][directoryOf(st.pageURL().String())]++
+	}
+}
+
+// coverage returns where the pages of the site lack each security header.
+func (c headerCounter) coverage() []*HeaderCoverage {
+	cov := []*HeaderCoverage{}
+	for _, h := range securityHeaders {
+		hc := &HeaderCoverage{Header: h, Hosts: []string{}, Paths: []*PathCount{}}
+		hosts := make(map[string]bool)
+		for dir, n := range c[h] {
+			hc.Missing += n
+			hc.Paths = append(hc.Paths, &PathCount{Path: dir, Count: n})
+			if u, err := url.Parse(dir); err == nil && !hosts[u.Host] {
+				hosts[u.Host] = true
+				hc.Hosts = append(hc.Hosts, u.Host)
+			}
+		}
+		sort.Strings(hc.Hosts)
+		sort.Sort(pathCountSort(hc.Paths))
+		if len(hc.Hosts) > summaryMaxHeaderList {
+			hc.Hosts = hc.Hosts[:summaryMaxHeaderList]
+		}
+		if len(hc.Paths) > summaryMaxHeaderList {
+			hc.Paths = hc.Paths[:summaryMaxHeaderList]
+		}
+		cov = append(cov, hc)
+	}
+	return cov
+}
+
+// pathCountSort orders directories by descending count, then by path.
+type pathCountSort []*PathCount
+
+func (s pathCountSort) Len() int      { return len(s) }
+func (s pathCountSort) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s pathCountSort) Less(i, j int) bool {
+	if s[i].Count != s[j].Count {
+		return s[i].Count > s[j].Count
+	}
+	return s[i].Path < s[j].Path
+}
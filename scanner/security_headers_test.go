@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCSPWeak(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		policy   string
+		expected bool
+	}{
+		{"default-src 'self'", false},
+		{"script-src 'self' https://cdn.example.com; object-src 'none'", false},
+		{"script-src 'self' 'unsafe-inline' 'nonce-abc'", false},
+		{"img-src *", true},
+		{"default-src 'self' 'unsafe-inline'", true},
+		{"script-src 'unsafe-eval'", true},
+		{"default-src 'self'; script-src *", true},
+		{"script-src https:", true},
+		{"SCRIPT-SRC 'self'; script-src *", false},
+	}
+	for _, tc := range tests {
+		if w := cspWeak(cspDirectives(tc.policy)); w != tc.expected {
+			t.Errorf("Invalid weakness for %q. Expected %t, received %t.", tc.policy, tc.expected, w)
+		}
+	}
+}
+
+func TestHSTSMaxAge(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"max-age=31536000; includeSubDomains", 31536000},
+		{`includeSubDomains; Max-Age="600"`, 600},
+		{"max-age=0", 0},
+		{"preload", -1},
+		{"max-age=soon", -1},
+	}
+	for _, tc := range tests {
+		if n := hstsMaxAge(tc.value); n != tc.expected {
+			t.Errorf("Invalid max-age for %q. Expected %d, received %d.", tc.value, tc.expected, n)
+		}
+	}
+}
+
+func TestReferrerPolicy(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"no-referrer", "no-referrer"},
+		{"no-referrer, Strict-Origin-When-Cross-Origin", "strict-origin-when-cross-origin"},
+		{"unsafe-url, unknown-policy", "unsafe-url"},
+		{"unknown-policy", ""},
+	}
+	for _, tc := range tests {
+		if p := referrerPolicy(tc.value); p != tc.expected {
+			t.Errorf("Invalid policy for %q. Expected %q, received %q.", tc.value, tc.expected, p)
+		}
+	}
+}
+
+func TestAuditSecurityHeaders(t *testing.T) {
+	t.Parallel()
+	secure := map[string]string{
+		"Strict-Transport-Security": "max-age=63072000",
+		"Content-Security-Policy":   "default-src 'self'",
+		"X-Content-Type-Options":    "nosniff",
+		"X-Frame-Options":           "SAMEORIGIN",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+		"Permissions-Policy":        "camera=()",
+	}
+	tests := []struct {
+		page     string
+		header   string
+		value    string
+		expected []string
+		message  string
+	}{
+		{"https://example.com/", "", "", []string{}, "Secure pages should pass."},
+		{"https://example.com/", "Strict-Transport-Security", "", []string{RuleHSTSMissing},
+			"Missing HSTS should be flagged."},
+		{"http://example.com/", "Strict-Transport-Security", "", []string{},
+			"HSTS should only be required over https."},
+		{"https://example.com/", "Strict-Transport-Security", "max-age=86400", []string{RuleHSTSWeak},
+			"Short HSTS should be flagged."},
+		{"http://example.com/", "Strict-Transport-Security", "max-age=86400", []string{},
+			"HSTS strength should only be checked over https."},
+		{"https://example.com/", "Content-Security-Policy", "", []string{RuleCSPMissing},
+			"Missing CSP should be flagged."},
+		{"https://example.com/", "Content-Security-Policy", "script-src *", []string{RuleCSPWeak},
+			"Weak CSP should be flagged."},
+		{"https://example.com/", "X-Content-Type-Options", "sniff", []string{RuleNoSniffMissing},
+			"nosniff should be required."},
+		{"https://example.com/", "X-Frame-Options", "", []string{RuleFrameMissing},
+			"Missing frame protection should be flagged."},
+		{"https://example.com/", "X-Frame-Options", "ALLOW-FROM https://a.com", []string{RuleFrameWeak},
+			"Obsolete frame options should be flagged."},
+		{"https://example.com/", "Referrer-Policy", "", []string{RuleReferrerMissing},
+			"Missing Referrer-Policy should be flagged."},
+		{"https://example.com/", "Referrer-Policy", "no-referrer-when-downgrade", []string{RuleReferrerWeak},
+			"Leaky Referrer-Policy should be flagged."},
+		{"https://example.com/", "Permissions-Policy", "", []string{RulePermissionsMissing},
+			"Missing Permissions-Policy should be flagged."},
+	}
+	for _, tc := range tests {
+		h := http.Header{}
+		for k, v := range secure {
+			if k == tc.header {
+				v = tc.value
+			}
+			if v != "" {
+				h.Set(k, v)
+			}
+		}
+		u, _ := url.Parse(tc.page)
+		j := scanJobNew(u, "html", nil)
+		auditSecurityHeaders(j, h)
+		if !reflect.DeepEqual(j.Stat.Issues, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, j.Stat.Issues)
+		}
+	}
+
+	h := http.Header{}
+	h.Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'self'")
+	u, _ := url.Parse("http://example.com/")
+	j := scanJobNew(u, "html", nil)
+	auditSecurityHeaders(j, h)
+	if j.Stat.hasIssue(RuleFrameMissing) {
+		t.Errorf("CSP frame-ancestors should protect against framing.")
+	}
+}
+
+func TestSummarizeSecurityHeaders(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	put := func(rawurl string, status int, headers ...string) {
+		u, _ := url.Parse(rawurl)
+		st := StatsNew(u, "html", s.RootURL)
+		st.StatusCode = status
+		st.ServedType = servedHTML
+		for _, h := range headers {
+			st.SecurityHeaders[h] = "set"
+		}
+		s.Store.PutResult(st)
+	}
+	put("https://example.com/", 200, "Content-Security-Policy")
+	put("https://example.com/blog/a", 200)
+	put("https://example.com/blog/b", 200, "Content-Security-Policy")
+	put("http://old.example.com/x", 200, "Content-Security-Policy")
+	put("https://example.com/missing", 404)
+	sum := s.Summarize()
+	if len(sum.SecurityHeaders) != len(securityHeaders) {
+		t.Fatalf("Every security header should have been reported.")
+	}
+	hsts, csp := sum.SecurityHeaders[0], sum.SecurityHeaders[1]
+	if hsts.Missing != 3 || !reflect.DeepEqual(hsts.Hosts, []string{"example.com"}) {
+		t.Errorf("HSTS should only be missing from https pages: %+v", hsts)
+	}
+	expected := []*PathCount{{"https://example.com/blog/", 1}}
+	if csp.Missing != 1 || !reflect.DeepEqual(csp.Paths, expected) {
+		t.Errorf("Invalid CSP coverage: %+v", csp)
+	}
+	if p := sum.SecurityHeaders[2].Paths; len(p) != 3 || p[0].Path != "https://example.com/blog/" || p[0].Count != 2 {
+		t.Errorf("Directories should be ordered by pages missing the header.")
+	}
+}
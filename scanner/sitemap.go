@@ -0,0 +1,194 @@
+package scanner
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	maxRobotsSize  = 500 << 10 // The largest robots.txt file read.
+	maxSitemaps    = 100       // The number of sitemap files read, including those listed in indexes.
+	maxSitemapSize = 50 << 20  // The largest sitemap file read, uncompressed, as the protocol allows.
+	maxSitemapURLs = 500000    // The number of sitemap URLs kept, so memory stays bounded.
+)
+
+var (
+	errSitemapNotFound = errors.New("Sitemap not found.") // Returned when a sitemap returns 404.
+)
+
+// sitemapFile is a sitemap, or a sitemap index listing other sitemaps.
+type sitemapFile struct {
+	URLs     []*sitemapEntry `xml:"url"`     // The URLs of a sitemap.
+	Sitemaps []*sitemapEntry `xml:"sitemap"` // The sitemaps of an index.
+}
+
+// sitemapEntry is a URL listed in a sitemap or sitemap index.
+type sitemapEntry struct {
+	Loc   string         `xml:"loc"`                               // The URL.
+	Links []*sitemapLink `xml:"http://www.w3.org/1999/xhtml link"` // The alternate versions of the URL.
+}
+
+// sitemapLink is an xhtml:link element of a sitemap URL
+// ex: <xhtml:link rel="alternate" hreflang="de" href="https://example.com/de/"/>.
+type sitemapLink struct {
+	Rel      string `xml:"rel,attr"`      // The relationship, alternate for hreflang annotations.
+	Hreflang string `xml:"hreflang,attr"` // The locale of the alternate version.
+	Href     string `xml:"href,attr"`     // The URL of the alternate version.
+}
+
+// sitemapKey returns a URL in the form used to look it up in the sitemaps, or "" if it
+// is not absolute. The root of a site is listed both with and without its slash.
+func sitemapKey(rawurl string) string {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil || !u.IsAbs() {
+		return ""
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// get requests a URL of the site outside of the workers.
+func (s *Scanner) get(cl *http.Client, u string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return cl.Do(req.WithContext(s.ctx))
+}
+
+// robotsSitemaps returns the sitemaps named by Sitemap: lines in the robots.txt file of
+// the site.
+func (s *Scanner) robotsSitemaps(cl *http.Client) []string {
+	sitemaps := []string{}
+	robots := s.RootURL.ResolveReference(&url.URL{Path: "/robots.txt"})
+	resp, err := s.get(cl, robots.String())
+	if err != nil {
+		return sitemaps
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return sitemaps
+	}
+	sc := bufio.NewScanner(io.LimitReader(resp.Body, maxRobotsSize))
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 || !strings.EqualFold(strings.TrimSpace(kv[0]), "sitemap") {
+			continue
+		}
+		if u, err := url.Parse(strings.TrimSpace(kv[1])); err == nil && u.String() != "" {
+			sitemaps = append(sitemaps, robots.ResolveReference(u).String())
+		}
+	}
+	return sitemaps
+}
+
+// readSitemap downloads and parses a sitemap or sitemap index. Sitemaps ending in .gz are
+// decompressed.
+func (s *Scanner) readSitemap(cl *http.Client, u string) (*sitemapFile, error) {
+	resp, err := s.get(cl, u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errSitemapNotFound
+	default:
+		return nil, fmt.Errorf("Sitemap returned status %d.", resp.StatusCode)
+	}
+	var body io.Reader = resp.Body
+	if strings.HasSuffix(strings.ToLower(resp.Request.URL.Path), ".gz") {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = gz
+	}
+	sm := &sitemapFile{}
+	if err := xml.NewDecoder(io.LimitReader(body, maxSitemapSize)).Decode(sm); err != nil {
+		return nil, err
+	}
+	return sm, nil
+}
+
+// loadSitemaps reads the sitemaps named in robots.txt, or /sitemap.xml if there are none,
+// and the sitemaps listed in sitemap indexes, and records the URLs they list with their
+// hreflang annotations. A site without the default sitemap is not warned about.
+func (s *Scanner) loadSitemaps(cl *http.Client) {
+	s.sitemap = make(map[string]bool)
+	s.sitemapHreflang = make(map[string][]*Alternate)
+	queue := s.robotsSitemaps(cl)
+	var fallback string
+	if len(queue) == 0 {
+		fallback = s.RootURL.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()
+		queue = append(queue, fallback)
+	}
+	read := make(map[string]bool)
+	for len(queue) > 0 && len(read) < maxSitemaps {
+		u := queue[0]
+		queue = queue[1:]
+		if read[u] {
+			continue
+		}
+		read[u] = true
+		sm, err := s.readSitemap(cl, u)
+		if err != nil {
+			if u != fallback || err != errSitemapNotFound {
+				s.log.Warningf("Unable to read sitemap %s: %s", u, err)
+			}
+			continue
+		}
+		for _, e := range sm.Sitemaps {
+			if k := sitemapKey(e.Loc); k != "" {
+				queue = append(queue, k)
+			}
+		}
+		for _, e := range sm.URLs {
+			k := sitemapKey(e.Loc)
+			if k == "" || (len(s.sitemap) >= maxSitemapURLs && !s.sitemap[k]) {
+				continue
+			}
+			s.sitemap[k] = true
+			for _, l := range e.Links {
+				s.sitemapAlternate(k, l)
+			}
+		}
+	}
+}
+
+// sitemapAlternate records the hreflang annotation of a sitemap URL.
+func (s *Scanner) sitemapAlternate(key string, l *sitemapLink) {
+	if !strings.EqualFold(strings.TrimSpace(l.Rel), "alternate") || l.Hreflang == "" ||
+		len(s.sitemapHreflang[key]) >= maxHreflangs {
+		return
+	}
+	page, _ := url.Parse(key)
+	target, ok := resolveCanonical(page, strings.TrimSpace(l.Href))
+	if !ok || l.Href == "" {
+		return
+	}
+	s.sitemapHreflang[key] = append(s.sitemapHreflang[key],
+		&Alternate{Lang: strings.TrimSpace(l.Hreflang), URL: target})
+}
+
+// inSitemap returns true if a URL is listed in the sitemaps of the site.
+func (s *Scanner) inSitemap(u string) bool {
+	return s.sitemap[sitemapKey(u)]
+}
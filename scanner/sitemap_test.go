@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSitemapKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://example.com", "http://example.com/"},
+		{" http://example.com/a#part ", "http://example.com/a"},
+		{"/relative", ""},
+		{"http://[bad", ""},
+	}
+	for _, tc := range tests {
+		if k := sitemapKey(tc.url); k != tc.expected {
+			t.Errorf("Invalid key for %q. Expected %q, received %q.", tc.url, tc.expected, k)
+		}
+	}
+}
+
+func TestLoadSitemaps(t *testing.T) {
+	t.Parallel()
+	var srvr *httptest.Server
+	h := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			io.WriteString(w, "User-agent: *\nDisallow: /private\n# Sitemap: /commented.xml\n"+
+				"Sitemap: /index.xml\nsitemap: "+srvr.URL+"/index.xml\n")
+		case "/index.xml":
+			io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?>`+
+				`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+
+				`<sitemap><loc>`+srvr.URL+`/pages.xml.gz</loc></sitemap>`+
+				`<sitemap><loc>`+srvr.URL+`/missing.xml</loc></sitemap></sitemapindex>`)
+		case "/pages.xml.gz":
+			gz := gzip.NewWriter(w)
+			io.WriteString(gz, `<?xml version="1.0" encoding="UTF-8"?>`+
+				`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" `+
+				`xmlns:xhtml="http://www.w3.org/1999/xhtml">`+
+				`<url><loc>`+srvr.URL+`</loc></url><url><loc> `+srvr.URL+`/a#top </loc>`+
+				`<xhtml:link rel="alternate" hreflang="de" href="/de/a"/>`+
+				`<xhtml:link rel="alternate" hreflang="en" href="`+srvr.URL+`/a"/>`+
+				`<xhtml:link rel="canonical" href="`+srvr.URL+`/c"/>`+
+				`<link rel="alternate" hreflang="fr" href="/not-xhtml"/></url>`+
+				`<url><loc>/relative</loc></url></urlset>`)
+			gz.Close()
+		default:
+			http.NotFound(w, r)
+		}
+	}
+	srvr = httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.RootURL, _ = url.Parse(srvr.URL)
+	s.loadSitemaps(&http.Client{Timeout: time.Second})
+	expected := map[string]bool{srvr.URL + "/": true, srvr.URL + "/a": true}
+	if !reflect.DeepEqual(s.sitemap, expected) {
+		t.Errorf("Invalid sitemap URLs. Expected: %v Received: %v", expected, s.sitemap)
+	}
+	if !s.inSitemap(srvr.URL) || s.inSitemap(srvr.URL+"/b") {
+		t.Errorf("Sitemap lookups should match stored URLs.")
+	}
+	alts := []*Alternate{{"de", srvr.URL + "/de/a"}, {"en", srvr.URL + "/a"}}
+	if h := s.sitemapHreflang[srvr.URL+"/a"]; !reflect.DeepEqual(h, alts) || len(s.sitemapHreflang) != 1 {
+		t.Errorf("Invalid sitemap hreflang annotations: %v", s.sitemapHreflang)
+	}
+}
+
+func TestLoadSitemapsDefault(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sitemap.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `<urlset><url><loc>http://example.com/a</loc></url></urlset>`)
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.RootURL, _ = url.Parse(srvr.URL)
+	s.loadSitemaps(&http.Client{Timeout: time.Second})
+	if len(s.sitemap) != 1 || !s.inSitemap("http://example.com/a") {
+		t.Errorf("The default sitemap should have been read: %v", s.sitemap)
+	}
+}
+
+func TestSummarizeSitemap(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.sitemap = map[string]bool{"http://example.com/": true, "http://example.com/a": true,
+		"http://example.com/b": true}
+	put := func(rawurl string, urlType string, noindex bool) {
+		u, _ := url.Parse(rawurl)
+		st := StatsNew(u, urlType, s.RootURL)
+		st.StatusCode = 200
+		st.NoIndex = noindex
+		s.Store.PutResult(st)
+	}
+	put("http://example.com", "html", true)
+	put("http://example.com/a", "html", false)
+	put("http://example.com/b", "img", true)
+	put("http://example.com/c", "html", true)
+	sum := s.Summarize()
+	if sum.Violations[RuleSitemapNoIndex] != 1 {
+		t.Errorf("Noindex pages in the sitemap should have been flagged: %v", sum.Violations)
+	}
+	if sum.SitemapURLs != 3 {
+		t.Errorf("Invalid sitemap URL count: %d", sum.SitemapURLs)
+	}
+}
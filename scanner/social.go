@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxSocialTags         = 50  // The number of Open Graph or Twitter Card tags recorded for a page.
+	ogTitleMax            = 95  // Open Graph titles longer than this are cut off in previews.
+	ogDescriptionMax      = 300 // Open Graph descriptions longer than this are cut off in previews.
+	twitterTitleMax       = 70  // Twitter Card titles longer than this are cut off in previews.
+	twitterDescriptionMax = 200 // Twitter Card descriptions longer than this are cut off in previews.
+)
+
+var (
+	// Open Graph properties every page should have.
+	ogRequired = []string{"og:title", "og:type", "og:image", "og:url"}
+
+	// Twitter Card types.
+	twitterCards = map[string]bool{
+		"summary":             true,
+		"summary_large_image": true,
+		"app":                 true,
+		"player":              true,
+	}
+
+	// Properties that name an image for the preview.
+	socialImages = map[string]bool{
+		"og:image":            true,
+		"og:image:url":        true,
+		"og:image:secure_url": true,
+		"twitter:image":       true,
+		"twitter:image:src":   true,
+	}
+)
+
+// socialFound records an Open Graph or Twitter Card tag ex: <meta property="og:title">.
+// Only the first value of a property is kept. Preview images are scanned so they are
+// link checked.
+func (a *bodyAnalyzer) socialFound(property string, content string) {
+	property = strings.ToLower(strings.TrimSpace(property))
+	var tags map[string]string
+	switch {
+	case strings.HasPrefix(property, "og:"):
+		tags = a.ScanJob.Stat.OpenGraph
+	case strings.HasPrefix(property, "twitter:"):
+		tags = a.ScanJob.Stat.TwitterCard
+	default:
+		return
+	}
+	if _, ok := tags[property]; !ok && len(tags) < maxSocialTags {
+		tags[property] = content
+	}
+	if socialImages[property] && content != "" {
+		u, err := url.Parse(content)
+		if err == nil {
+			a.ScanJob.Children = append(a.ScanJob.Children, &scanJobChild{
+				URL:     u,
+				URLType: "img",
+			})
+		}
+	}
+}
+
+// socialViolations returns the Open Graph and Twitter Card rules a page breaks. Twitter
+// falls back to the Open Graph title, description and image when its own are missing.
+func (s *Stats) socialViolations() []string {
+	v := []string{}
+	og := s.OpenGraph
+	tw := s.TwitterCard
+	for _, p := range ogRequired {
+		if og[p] == "" {
+			v = append(v, RuleOGMissing)
+			break
+		}
+	}
+	if utf8.RuneCountInString(og["og:title"]) > ogTitleMax ||
+		utf8.RuneCountInString(og["og:description"]) > ogDescriptionMax {
+		v = append(v, RuleOGSize)
+	}
+	if ogURL := og["og:url"]; ogURL != "" {
+		c := canonicalOf(s)
+		if u, ok := resolveCanonical(s.pageURL(), ogURL); ok && c != "" && u != c {
+			v = append(v, RuleOGURLMismatch)
+		}
+	}
+
+	title := tw["twitter:title"]
+	if title == "" {
+		title = og["og:title"]
+	}
+	card := tw["twitter:card"]
+	switch {
+	case card == "" || title == "":
+		v = append(v, RuleTwitterMissing)
+	case !twitterCards[card]:
+		v = append(v, RuleTwitterCard)
+	case card == "summary_large_image" && tw["twitter:image"] == "" && tw["twitter:image:src"] == "" &&
+		og["og:image"] == "":
+		v = append(v, RuleTwitterMissing)
+	}
+	if utf8.RuneCountInString(tw["twitter:title"]) > twitterTitleMax ||
+		utf8.RuneCountInString(tw["twitter:description"]) > twitterDescriptionMax {
+		v = append(v, RuleTwitterSize)
+	}
+	return v
+}
@@ -0,0 +1,101 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSocialTags gives a result a valid set of Open Graph and Twitter Card tags.
+func testSocialTags(st *Stats) {
+	st.OpenGraph = map[string]string{
+		"og:title": "Title",
+		"og:type":  "website",
+		"og:image": "http://www.example.com/preview.png",
+		"og:url":   st.URL.String(),
+	}
+	st.TwitterCard = map[string]string{"twitter:card": "summary"}
+}
+
+func TestSocialAnalyze(t *testing.T) {
+	t.Parallel()
+	s := `<meta property="og:title" content="Shoes"><meta property="og:title" content="Other">` +
+		`<meta property="og:image" content="http://www.example.com/preview.png">` +
+		`<meta name="twitter:card" content="summary_large_image">` +
+		`<meta name="twitter:image" content="/card.png"><meta property="article:author" content="Me">`
+	j := scanJobNew(testURLRoot, "html", nil)
+	j.Body = ioutil.NopCloser(bytes.NewBufferString(s))
+	bodyAnalyzerNew(j).analyzeBody()
+	if !reflect.DeepEqual(j.Stat.OpenGraph, map[string]string{
+		"og:title": "Shoes",
+		"og:image": "http://www.example.com/preview.png",
+	}) {
+		t.Errorf("Invalid Open Graph properties: %v", j.Stat.OpenGraph)
+	}
+	if j.Stat.TwitterCard["twitter:card"] != "summary_large_image" || len(j.Stat.TwitterCard) != 2 {
+		t.Errorf("Invalid Twitter Card tags: %v", j.Stat.TwitterCard)
+	}
+	if len(j.Children) != 2 || j.Children[0].URLType != "img" || j.Children[1].URL.String() != "/card.png" {
+		t.Errorf("Preview images should have been queued as images.")
+	}
+}
+
+func TestSocialViolations(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		og        map[string]string
+		tw        map[string]string
+		canonical string
+		expected  []string
+		message   string
+	}{
+		{nil, nil, "", []string{}, "Valid tags should not report violations."},
+		{map[string]string{"og:image": ""}, nil, "", []string{RuleOGMissing},
+			"Missing Open Graph property should have been reported."},
+		{map[string]string{"og:title": strings.Repeat("x", ogTitleMax+1)}, nil, "", []string{RuleOGSize},
+			"Long Open Graph title should have been reported."},
+		{map[string]string{"og:url": "/other"}, nil, "http://www.example.com/faq", []string{RuleOGURLMismatch},
+			"Open Graph URL that isn't canonical should have been reported."},
+		{map[string]string{"og:url": "/faq"}, nil, "http://www.example.com/faq", []string{},
+			"Relative Open Graph URL matching the canonical should not be reported."},
+		{nil, map[string]string{"twitter:card": ""}, "", []string{RuleTwitterMissing},
+			"Missing Twitter Card should have been reported."},
+		{nil, map[string]string{"twitter:card": "big"}, "", []string{RuleTwitterCard},
+			"Invalid Twitter Card should have been reported."},
+		{map[string]string{"og:image": ""}, map[string]string{"twitter:card": "summary_large_image"}, "",
+			[]string{RuleOGMissing, RuleTwitterMissing}, "Large image card without an image should have been reported."},
+		{nil, map[string]string{"twitter:description": strings.Repeat("x", twitterDescriptionMax+1)}, "",
+			[]string{RuleTwitterSize}, "Long Twitter description should have been reported."},
+	}
+	for _, tc := range tests {
+		u, _ := url.Parse("http://www.example.com/faq")
+		st := StatsNew(u, "html", nil)
+		testSocialTags(st)
+		for k, v := range tc.og {
+			st.OpenGraph[k] = v
+		}
+		for k, v := range tc.tw {
+			st.TwitterCard[k] = v
+		}
+		st.CanonicalURL = tc.canonical
+		if v := st.socialViolations(); !reflect.DeepEqual(v, tc.expected) {
+			t.Errorf("%s Expected: %v Received: %v", tc.message, tc.expected, v)
+		}
+	}
+}
+
+func TestSocialRedirected(t *testing.T) {
+	t.Parallel()
+	u, _ := url.Parse("http://www.example.com/faq")
+	st := StatsNew(u, "html", nil)
+	testSocialTags(st)
+	st.RedirectURL = "http://www.example.com/en/faq"
+	st.CanonicalURL = "http://www.example.com/en/faq"
+	st.OpenGraph["og:url"] = "faq"
+	if v := st.socialViolations(); len(v) != 0 {
+		t.Errorf("Open Graph URL should be resolved against the redirect target: %v", v)
+	}
+}
@@ -8,30 +8,106 @@ import (
 
 // Stats is a construct that hold information on the scanning of a URL.
 type Stats struct {
-	URL           *url.URL  `json:"url"`           // The URL we scanned.
-	URLType       string    `json:"urlType"`       // The type of url ex: html, img, css, js etc..
-	ParentURL     *url.URL  `json:"parentURL"`     // The parent where this was located.
-	StartTime     time.Time `json:"startTime"`     // The start time of the scan.
-	EndTime       time.Time `json:"endTime"`       // The end time of the scan.
-	Canonical     bool      `json:"canonical"`     // Did this page contain a canonical link?
-	MetaCount     int       `json:"metaCount"`     // Does meta description exist on the page?
-	MetaSizedErr  bool      `json:"metaSizedErr"`  // Are meta descriptions the proper size?
-	TitleCount    int       `json:"titleCount"`    // Does title exist on the page?
-	TitleSizedErr bool      `json:"titleSizedErr"` // Does the title meet size criteria?
-	AltTagsErr    bool      `json:"altTagsErr"`    // Did alt tags exist for all images on this page?
-	H1Count       int       `json:"h1Count"`       // Does an h1 tag exist on the page and is it unique?
-	StatusCode    int       `json:"status"`        // The status code we returned from the scan.
+	URL             *url.URL          `json:"url"`             // The URL we scanned.
+	URLType         string            `json:"urlType"`         // The type of url ex: html, img, css, js etc..
+	ParentURL       *url.URL          `json:"parentURL"`       // The parent where this was located.
+	StartTime       time.Time         `json:"startTime"`       // The start time of the scan.
+	EndTime         time.Time         `json:"endTime"`         // The end time of the scan.
+	Canonical       bool              `json:"canonical"`       // Did this page contain a canonical link?
+	CanonicalURL    string            `json:"canonicalURL"`    // The resolved target of the canonical link.
+	MetaCount       int               `json:"metaCount"`       // Does meta description exist on the page?
+	MetaSizedErr    bool              `json:"metaSizedErr"`    // Are meta descriptions the proper size?
+	TitleCount      int               `json:"titleCount"`      // Does title exist on the page?
+	TitleSizedErr   bool              `json:"titleSizedErr"`   // Does the title meet size criteria?
+	AltTagsErr      bool              `json:"altTagsErr"`      // Did alt tags exist for all images on this page?
+	H1Count         int               `json:"h1Count"`         // Does an h1 tag exist on the page and is it unique?
+	StatusCode      int               `json:"status"`          // The status code we returned from the scan.
+	ETag            string            `json:"etag"`            // The ETag header returned from the scan.
+	LastModified    string            `json:"lastModified"`    // The Last-Modified header returned from the scan.
+	Reused          bool              `json:"reused"`          // Was the result of a previous run reused (not modified)?
+	Method          string            `json:"method"`          // The HTTP method used for the scan ex: HEAD, GET.
+	ContentType     string            `json:"contentType"`     // The Content-Type header returned from the scan.
+	ContentLength   int64             `json:"contentLength"`   // The Content-Length returned from the scan, -1 if unknown.
+	ServedType      string            `json:"servedType"`      // The type of url actually served, from its Content-Type.
+	Charset         string            `json:"charset"`         // The character encoding of an analyzed page.
+	Transferred     int64             `json:"transferred"`     // The number of body bytes read from the response.
+	Timing          Timing            `json:"timing"`          // How long each phase of the request took.
+	NoIndex         bool              `json:"noindex"`         // Do robots directives forbid indexing the URL?
+	NoFollow        bool              `json:"nofollow"`        // Do robots directives forbid following the links?
+	CanonicalHeader string            `json:"canonicalHeader"` // The resolved canonical URL from the Link header.
+	RedirectURL     string            `json:"redirectURL"`     // Where the URL redirected to, if it did.
+	Title           string            `json:"title"`           // The text of the first title.
+	Description     string            `json:"description"`     // The first meta description.
+	H1              string            `json:"h1"`              // The text of the first h1.
+	Headings        []*Heading        `json:"headings"`        // The h1 to h6 outline of the page.
+	OpenGraph       map[string]string `json:"openGraph"`       // Open Graph properties ex: og:title.
+	TwitterCard     map[string]string `json:"twitterCard"`     // Twitter Card tags ex: twitter:card.
+	StructuredData  []*StructuredItem `json:"structuredData"`  // Structured data items found on the page.
+	Hreflang        []*Alternate      `json:"hreflang"`        // The hreflang annotations of the page.
+	Anchors         []string          `json:"anchors"`         // The element ids and a names links can point to.
+	Fragments       []string          `json:"fragments"`       // The links to anchors, with their fragments.
+	Links           []*Link           `json:"links"`           // The a elements of the page.
+	Insecure        []string          `json:"insecure"`        // The http URLs referenced by an https page.
+	SecurityHeaders map[string]string `json:"securityHeaders"` // The security headers of an html response.
+	Issues          []string          `json:"issues"`          // Rule violations found while scanning.
 }
 
 // StatsNew is a factory for creating a new Stats instance.
 func StatsNew(u *url.URL, ut string, p *url.URL) *Stats {
 	return &Stats{
-		URL:       u,
-		URLType:   ut,
-		ParentURL: p,
+		URL:             u,
+		URLType:         ut,
+		ParentURL:       p,
+		Headings:        []*Heading{},
+		OpenGraph:       make(map[string]string),
+		TwitterCard:     make(map[string]string),
+		StructuredData:  []*StructuredItem{},
+		Hreflang:        []*Alternate{},
+		Anchors:         []string{},
+		Fragments:       []string{},
+		Links:           []*Link{},
+		Insecure:        []string{},
+		SecurityHeaders: make(map[string]string),
+		Issues:          []string{},
 	}
 }
 
+// addIssue records a rule violation found while scanning, once.
+func (s *Stats) addIssue(rule string) {
+	if !s.hasIssue(rule) {
+		s.Issues = append(s.Issues, rule)
+	}
+}
+
+// hasIssue returns true if a rule violation was found while scanning.
+func (s *Stats) hasIssue(rule string) bool {
+	for _, i := range s.Issues {
+		if i == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// isPage returns true if the URL was served as an html page. If the served type is not
+// known, the type of the reference is trusted.
+func (s *Stats) isPage() bool {
+	if s.ServedType != "" {
+		return s.ServedType == "html"
+	}
+	return isPageType(s.URLType)
+}
+
+// pageURL returns the URL the page was served from, after any redirects.
+func (s *Stats) pageURL() *url.URL {
+	if s.RedirectURL != "" {
+		if u, err := url.Parse(s.RedirectURL); err == nil {
+			return u
+		}
+	}
+	return s.URL
+}
+
 // String is an implentation of the Stringer interface so the structure is returned as a
 // string to fmt.Print() etc.
 func (s *Stats) String() string {
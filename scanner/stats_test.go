@@ -12,8 +12,15 @@ const (
 		`"www.example.com","Path":"/faq","RawQuery":"","Fragment":""},"urlType":"html",` +
 		`"parentURL":{"Scheme":"http","Opaque":"","User":null,"Host":"www.example.com",` +
 		`"Path":"","RawQuery":"","Fragment":""},"startTime":"0001-01-01T00:00:00Z",` +
-		`"endTime":"0001-01-01T00:00:00Z","canonical":false,"metaCount":0,"metaSizedErr":` +
-		`false,"titleCount":0,"titleSizedErr":false,"altTagsErr":false,"h1Count":0,"status":0}`
+		`"endTime":"0001-01-01T00:00:00Z","canonical":false,"canonicalURL":"",` +
+		`"metaCount":0,"metaSizedErr":` +
+		`false,"titleCount":0,"titleSizedErr":false,"altTagsErr":false,"h1Count":0,"status":0,"etag":"",` +
+		`"lastModified":"","reused":false,` +
+		`"method":"","contentType":"","contentLength":0,` +
+		`"servedType":"","charset":"","transferred":0,` +
+		`"timing":{"dns":0,"connect":0,"tls":0,"firstByte":0,"download":0,"total":0},"noindex":false,"nofollow":false,"canonicalHeader":"","redirectURL":"",` +
+		`"title":"","description":"","h1":"","headings":[],"openGraph":{},"twitterCard":{},"structuredData":[],"hreflang":[],"anchors":[],"fragments":[],"links":[],"insecure":[],"securityHeaders":{},` +
+		`"issues":[]}`
 )
 
 func TestStatsNew(t *testing.T) {
@@ -69,6 +76,90 @@ func TestStatsNew(t *testing.T) {
 	if fmt.Sprint(reflect.TypeOf(stat.StatusCode)) != "int" {
 		t.Errorf("int expected.")
 	}
+	if fmt.Sprint(reflect.TypeOf(stat.ETag)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.LastModified)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Reused)) != "bool" {
+		t.Errorf("bool expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Method)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.ContentType)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.ContentLength)) != "int64" {
+		t.Errorf("int64 expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.ServedType)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Charset)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Transferred)) != "int64" {
+		t.Errorf("int64 expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Timing)) != "scanner.Timing" {
+		t.Errorf("scanner.Timing expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.NoIndex)) != "bool" {
+		t.Errorf("bool expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.NoFollow)) != "bool" {
+		t.Errorf("bool expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.CanonicalURL)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.CanonicalHeader)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.RedirectURL)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Title)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Description)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.H1)) != "string" {
+		t.Errorf("string expected.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Headings)) != "[]*scanner.Heading" {
+		t.Errorf("[]*scanner.Heading not initialized.")
+	}
+	if stat.OpenGraph == nil || stat.TwitterCard == nil {
+		t.Errorf("Social maps not initialized.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.StructuredData)) != "[]*scanner.StructuredItem" {
+		t.Errorf("[]*scanner.StructuredItem not initialized.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Hreflang)) != "[]*scanner.Alternate" {
+		t.Errorf("[]*scanner.Alternate not initialized.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Anchors)) != "[]string" {
+		t.Errorf("Anchors not initialized.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Fragments)) != "[]string" {
+		t.Errorf("Fragments not initialized.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Links)) != "[]*scanner.Link" {
+		t.Errorf("Links not initialized.")
+	}
+	if fmt.Sprint(reflect.TypeOf(stat.Insecure)) != "[]string" {
+		t.Errorf("Insecure not initialized.")
+	}
+	if stat.SecurityHeaders == nil {
+		t.Errorf("SecurityHeaders not initialized.")
+	}
+	if len(stat.Issues) != 0 {
+		t.Errorf("Issues not initialized.")
+	}
 }
 
 func TestStatsPrint(t *testing.T) {
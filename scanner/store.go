@@ -0,0 +1,49 @@
+package scanner
+
+import (
+	"errors"
+	"net/url"
+)
+
+var (
+	errStopEach = errors.New("Visit stopped.") // Returned by visitors to end a visit early.
+)
+
+// Store holds the crawl frontier, the jobs waiting to be scanned, and the URL test
+// results. Results are keyed by URL and then by the parent URL where it was found.
+type Store interface {
+	PushJob(j *scanJob) error                                            // Add a job to the frontier.
+	PopJob() (*scanJob, error)                                           // Remove the next job, or nil if empty.
+	EachJob(fn func(j *scanJob) error) error                             // Visit the jobs in the frontier.
+	JobLen() int                                                         // The number of jobs in the frontier.
+	PutResult(st *Stats) error                                           // Store a result.
+	PutPage(st *Stats, jobs []*scanJob) error                            // Store a result, if not nil, and its new jobs at once.
+	Result(u string, p string) (*Stats, error)                           // Get a result, or nil if not found.
+	Results(u string) (map[string]*Stats, error)                         // Get the results of a URL for every parent.
+	EachResult(fn func(u string, parents map[string]*Stats) error) error // Visit the results in URL order.
+	ResultLen() int                                                      // The number of URLs with results.
+	Persistent() bool                                                    // Does the store survive a restart?
+	Reset() error                                                        // Remove all jobs and results.
+	Close() error                                                        // Release the store.
+}
+
+// storedJob is the representation of a job kept in a store or checkpoint.
+type storedJob struct {
+	URL       *url.URL `json:"url"`       // The URL to scan.
+	URLType   string   `json:"urlType"`   // The type of url ex: html, img, css, js etc..
+	ParentURL *url.URL `json:"parentURL"` // The parent where this was located.
+}
+
+// storedJobNew is a factory for creating the stored representation of a job.
+func storedJobNew(j *scanJob) *storedJob {
+	return &storedJob{
+		URL:       j.Stat.URL,
+		URLType:   j.Stat.URLType,
+		ParentURL: j.Stat.ParentURL,
+	}
+}
+
+// job returns a new scan job for the stored job.
+func (s *storedJob) job() *scanJob {
+	return scanJobNew(s.URL, s.URLType, s.ParentURL)
+}
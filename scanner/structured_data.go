@@ -0,0 +1,238 @@
+package scanner
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxStructuredItems = 50 // The number of structured data items recorded for a page.
+)
+
+// Structured data formats.
+const (
+	formatJSONLD    = "json-ld"
+	formatMicrodata = "microdata"
+	formatRDFa      = "rdfa"
+)
+
+var (
+	// The properties each schema.org type must have. Alternatives are separated by "|".
+	schemaRequired = map[string][]string{
+		"Organization":   {"name", "url"},
+		"Product":        {"name", "offers|review|aggregateRating"},
+		"Article":        {"headline", "author", "datePublished"},
+		"NewsArticle":    {"headline", "author", "datePublished"},
+		"BlogPosting":    {"headline", "author", "datePublished"},
+		"BreadcrumbList": {"itemListElement"},
+		"FAQPage":        {"mainEntity"},
+	}
+
+	// Elements that never have an end tag.
+	voidElements = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+		"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+	}
+)
+
+// StructuredItem is a top level item of structured data found on a page.
+type StructuredItem struct {
+	Format  string   `json:"format"`  // How the item was written ex: json-ld, microdata, rdfa.
+	Type    string   `json:"type"`    // The schema.org type ex: Product.
+	Missing []string `json:"missing"` // Required properties the item doesn't have.
+}
+
+// structuredScope is a microdata or RDFa item whose properties are being read.
+type structuredScope struct {
+	item  *StructuredItem // The item, or nil for nested items which are not recorded.
+	props map[string]bool // The properties found.
+	depth int             // The element depth of the item's children.
+}
+
+// schemaType returns the short name of a schema.org type ex: "https://schema.org/Product"
+// or "schema:Product" => "Product". Only the first of several types is used.
+func schemaType(t string) string {
+	fields := strings.Fields(t)
+	if len(fields) == 0 {
+		return ""
+	}
+	t = fields[0]
+	if i := strings.LastIndexAny(t, "/:#"); i >= 0 {
+		t = t[i+1:]
+	}
+	return t
+}
+
+// schemaMissing returns the required properties of a type that were not found.
+func schemaMissing(t string, props map[string]bool) []string {
+	missing := []string{}
+	for _, req := range schemaRequired[t] {
+		var found bool
+		for _, p := range strings.Split(req, "|") {
+			found = found || props[p]
+		}
+		if !found {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// addStructuredItem records a top level structured data item on the page.
+func (a *bodyAnalyzer) addStructuredItem(format string, t string) *StructuredItem {
+	if len(a.ScanJob.Stat.StructuredData) >= maxStructuredItems {
+		return nil
+	}
+	item := &StructuredItem{Format: format, Type: schemaType(t), Missing: []string{}}
+	a.ScanJob.Stat.StructuredData = append(a.ScanJob.Stat.StructuredData, item)
+	return item
+}
+
+// structuredJSON reads the JSON-LD in a script element. Blocks that aren't valid JSON are
+// flagged.
+func (a *bodyAnalyzer) structuredJSON(p *html.Tokenizer) {
+	text, ok := a.elementText(p)
+	if !ok {
+		return
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		a.ScanJob.Stat.addIssue(RuleStructuredSyntax)
+		return
+	}
+	a.structuredNode(v)
+}
+
+// structuredNode records a JSON-LD node, or each node of a list or @graph.
+func (a *bodyAnalyzer) structuredNode(v interface{}) {
+	switch n := v.(type) {
+	case []interface{}:
+		for _, e := range n {
+			a.structuredNode(e)
+		}
+	case map[string]interface{}:
+		if g, ok := n["@graph"]; ok {
+			a.structuredNode(g)
+		}
+		var t string
+		switch nt := n["@type"].(type) {
+		case string:
+			t = nt
+		case []interface{}:
+			if len(nt) > 0 {
+				t, _ = nt[0].(string)
+			}
+		}
+		if t == "" {
+			return
+		}
+		props := make(map[string]bool)
+		for k := range n {
+			props[k] = true
+		}
+		if item := a.addStructuredItem(formatJSONLD, t); item != nil {
+			item.Missing = schemaMissing(item.Type, props)
+		}
+	}
+}
+
+// structuredTag reads the microdata (itemscope, itemtype, itemprop) and RDFa (typeof,
+// property) attributes of an element. Element depth is tracked to know when an item
+// ends; pages that leave elements unclosed can make items appear to end late.
+func (a *bodyAnalyzer) structuredTag(tk html.Token, selfClosing bool) {
+	var scope, prop, itemType string
+	var itemscope bool
+	for _, attr := range tk.Attr {
+		switch attr.Key {
+		case "itemscope":
+			itemscope = true
+		case "itemtype":
+			itemType = attr.Val
+		case "itemprop", "property":
+			prop = attr.Val
+		case "typeof":
+			scope = formatRDFa
+			itemType = attr.Val
+		}
+	}
+	if itemscope {
+		scope = formatMicrodata
+	}
+	// Properties belong to the innermost item, even on an element that starts a new item.
+	if prop != "" && len(a.scopes) > 0 {
+		for _, p := range strings.Fields(prop) {
+			if i := strings.LastIndex(p, ":"); i >= 0 {
+				p = p[i+1:]
+			}
+			a.scopes[len(a.scopes)-1].props[p] = true
+		}
+	}
+	hasChildren := !selfClosing && !voidElements[tk.DataAtom.String()]
+	if hasChildren {
+		a.depth++
+	}
+	if scope == "" {
+		return
+	}
+	s := &structuredScope{props: make(map[string]bool), depth: a.depth}
+	if prop == "" {
+		s.item = a.addStructuredItem(scope, itemType)
+	}
+	if hasChildren {
+		a.scopes = append(a.scopes, s)
+	} else {
+		a.endScope(s)
+	}
+}
+
+// structuredEnd closes an element, ending the items it started.
+func (a *bodyAnalyzer) structuredEnd() {
+	a.depth--
+	for len(a.scopes) > 0 && a.scopes[len(a.scopes)-1].depth > a.depth {
+		a.endScope(a.scopes[len(a.scopes)-1])
+		a.scopes = a.scopes[:len(a.scopes)-1]
+	}
+}
+
+// finishStructured ends the items still open at the end of the page.
+func (a *bodyAnalyzer) finishStructured() {
+	for i := len(a.scopes) - 1; i >= 0; i-- {
+		a.endScope(a.scopes[i])
+	}
+	a.scopes = nil
+	a.depth = 0
+}
+
+// endScope checks the properties of an item once all have been read.
+func (a *bodyAnalyzer) endScope(s *structuredScope) {
+	if s.item != nil {
+		s.item.Missing = schemaMissing(s.item.Type, s.props)
+	}
+}
+
+// structuredViolations returns the structured data rules a page breaks.
+func (s *Stats) structuredViolations() []string {
+	for _, item := range s.StructuredData {
+		if len(item.Missing) > 0 {
+			return []string{RuleStructuredMissing}
+		}
+	}
+	return []string{}
+}
+
+// structuredTypes returns the distinct structured data types on a page in order.
+func (s *Stats) structuredTypes() []string {
+	seen := make(map[string]bool)
+	types := []string{}
+	for _, item := range s.StructuredData {
+		if item.Type != "" && !seen[item.Type] {
+			seen[item.Type] = true
+			types = append(types, item.Type)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,94 @@
+package scanner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaType(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		t        string
+		expected string
+	}{
+		{"Product", "Product"},
+		{"https://schema.org/Product", "Product"},
+		{"http://schema.org/Article http://schema.org/NewsArticle", "Article"},
+		{"schema:Organization", "Organization"},
+		{"", ""},
+	}
+	for _, tc := range tests {
+		if s := schemaType(tc.t); s != tc.expected {
+			t.Errorf("Invalid type for %q. Expected %q, received %q.", tc.t, tc.expected, s)
+		}
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		body          string
+		expected      []*StructuredItem
+		expectedIssue bool
+		message       string
+	}{
+		{`<script type="application/ld+json">{"@context":"https://schema.org","@type":"Organization",` +
+			`"name":"Shop","url":"http://www.example.com"}</script>`,
+			[]*StructuredItem{{formatJSONLD, "Organization", []string{}}}, false,
+			"Valid JSON-LD should have been recorded."},
+		{`<script type="application/ld+json">{"@graph":[{"@type":"Product","name":"Shoe"},` +
+			`{"@type":["BreadcrumbList"],"itemListElement":[]}]}</script>`,
+			[]*StructuredItem{{formatJSONLD, "Product", []string{"offers|review|aggregateRating"}},
+				{formatJSONLD, "BreadcrumbList", []string{}}}, false,
+			"JSON-LD graph should have been recorded and checked."},
+		{`<script type="application/ld+json">{"@type": "Article",</script>`,
+			[]*StructuredItem{}, true, "Invalid JSON should have been flagged."},
+		{`<div itemscope itemtype="https://schema.org/Product"><span itemprop="name">Shoe</span>` +
+			`<div itemprop="offers" itemscope itemtype="https://schema.org/Offer"><span itemprop="price">1</span>` +
+			`<img itemprop="image" src="/shoe.png"></div></div>` +
+			`<div itemscope itemtype="https://schema.org/FAQPage"><br><p>No questions</p></div>`,
+			[]*StructuredItem{{formatMicrodata, "Product", []string{}},
+				{formatMicrodata, "FAQPage", []string{"mainEntity"}}}, false,
+			"Microdata should have been recorded and checked."},
+		{`<body vocab="https://schema.org/"><div typeof="Article"><h1 property="headline">News</h1>` +
+			`<span property="schema:author">Me</span></div><p property="datePublished">Today</p></body>`,
+			[]*StructuredItem{{formatRDFa, "Article", []string{"datePublished"}}}, false,
+			"RDFa should have been recorded and checked."},
+		{`<div itemscope itemtype="https://schema.org/FAQPage"><title></title><style></style>` +
+			`<script type="application/ld+json"></script></div><p itemprop="mainEntity">Outside</p>`,
+			[]*StructuredItem{{formatMicrodata, "FAQPage", []string{"mainEntity"}}}, false,
+			"Empty elements should have ended with the item."},
+	}
+	for _, tc := range tests {
+		j := scanJobNew(testURLRoot, "html", nil)
+		j.Body = ioutil.NopCloser(bytes.NewBufferString(tc.body))
+		bodyAnalyzerNew(j).analyzeBody()
+		if !reflect.DeepEqual(j.Stat.StructuredData, tc.expected) {
+			t.Errorf("%s", tc.message)
+			for _, item := range j.Stat.StructuredData {
+				t.Errorf("Received: %+v", item)
+			}
+		}
+		if (len(j.Stat.Issues) > 0) != tc.expectedIssue {
+			t.Errorf("%s Issues: %v", tc.message, j.Stat.Issues)
+		}
+	}
+}
+
+func TestStructuredViolations(t *testing.T) {
+	t.Parallel()
+	st := StatsNew(testURLRoot, "html", nil)
+	st.StructuredData = []*StructuredItem{
+		{formatJSONLD, "Product", []string{}},
+		{formatMicrodata, "Product", []string{"name"}},
+		{formatMicrodata, "WebSite", []string{}},
+	}
+	if v := st.structuredViolations(); !reflect.DeepEqual(v, []string{RuleStructuredMissing}) {
+		t.Errorf("Missing properties should have been reported: %v", v)
+	}
+	if types := st.structuredTypes(); !reflect.DeepEqual(types, []string{"Product", "WebSite"}) {
+		t.Errorf("Invalid structured data types: %v", types)
+	}
+}
@@ -0,0 +1,438 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	summaryMaxSlowest = 10 // The number of slowest URLs reported.
+	summaryMaxBroken  = 10 // The number of broken targets reported.
+)
+
+// Reasons why a scan ended.
+const (
+	StopIdle    = "idle"    // No more jobs were found to process.
+	StopExpired = "expired" // The maximum run time was reached.
+	StopLimit   = "limit"   // The job queue was full.
+	StopSignal  = "signal"  // An operating system signal was received.
+)
+
+// SlowURL is a URL and how long it took to scan.
+type SlowURL struct {
+	URL      string `json:"url"`      // The URL scanned.
+	URLType  string `json:"urlType"`  // The type of url ex: html, img, css, js etc..
+	Duration int64  `json:"duration"` // The duration of the scan in milliseconds.
+}
+
+// BrokenTarget is a URL that failed to load and the pages that refer to it.
+type BrokenTarget struct {
+	URL            string   `json:"url"`            // The URL that failed.
+	URLType        string   `json:"urlType"`        // The type of url ex: html, img, css, js etc..
+	StatusCode     int      `json:"status"`         // The status code returned from the scan.
+	Referrers      int      `json:"referrers"`      // How many pages refer to this URL.
+	ReferringPages []string `json:"referringPages"` // The pages that refer to this URL.
+}
+
+// Summary is an aggregation of all the results of a scan.
+type Summary struct {
+	RootURL               string                  `json:"rootURL"`               // The original URL that we started the scan from.
+	StartTime             time.Time               `json:"startTime"`             // When the scanner started runnning.
+	EndTime               time.Time               `json:"endTime"`               // When the scanner ended.
+	StopReason            string                  `json:"stopReason"`            // Why the scan ended ex: idle, expired, limit, signal.
+	Partial               bool                    `json:"partial"`               // Was the scan stopped before all URLs were scanned?
+	Requests              int                     `json:"requests"`              // The number of URL scans completed.
+	Pages                 int                     `json:"pages"`                 // Total html pages scanned.
+	Assets                int                     `json:"assets"`                // Total assets (img, css, js etc.) scanned.
+	Reused                int                     `json:"reused"`                // URLs not modified since the previous run.
+	Refetched             int                     `json:"refetched"`             // URLs downloaded and analyzed.
+	NoIndex               int                     `json:"noindex"`               // URLs robots may not index.
+	NoFollow              int                     `json:"nofollow"`              // URLs whose links robots may not follow.
+	SitemapURLs           int                     `json:"sitemapURLs"`           // URLs listed in the sitemaps of the site.
+	StatusClasses         map[string]int          `json:"statusClasses"`         // Count of URLs by status class ex: 2xx, 4xx, error.
+	URLTypes              map[string]int          `json:"urlTypes"`              // Count of URLs by type.
+	ServedTypes           map[string]int          `json:"servedTypes"`           // Count of URLs by type actually served.
+	StructuredTypes       map[string]int          `json:"structuredTypes"`       // Count of pages by structured data type.
+	Violations            map[string]int          `json:"violations"`            // Count of pages by SEO rule violation.
+	Slowest               []*SlowURL              `json:"slowest"`               // The slowest URLs scanned.
+	Broken                []*BrokenTarget         `json:"broken"`                // The broken URLs with the most referring pages.
+	Canonicalized         int                     `json:"canonicalized"`         // Pages that name another URL as canonical.
+	Canonical             []*CanonicalPage        `json:"canonical"`             // Pages that canonicalize elsewhere, problems first.
+	HreflangPages         int                     `json:"hreflangPages"`         // Pages with hreflang annotations.
+	HreflangProblemCount  int                     `json:"hreflangProblemCount"`  // Hreflang annotations whose targets disagree.
+	HreflangProblems      []*HreflangProblem      `json:"hreflangProblems"`      // The first hreflang annotations whose targets disagree.
+	BrokenFragmentCount   int                     `json:"brokenFragmentCount"`   // Links to anchors that don't exist.
+	BrokenFragments       []*BrokenFragment       `json:"brokenFragments"`       // The broken anchor links with the most referring pages.
+	LinkGraph             *LinkGraph              `json:"linkGraph"`             // The analysis of the internal links between pages.
+	AnchorText            []*AnchorDistribution   `json:"anchorText"`            // The link text of the most linked pages.
+	SecurityHeaders       []*HeaderCoverage       `json:"securityHeaders"`       // Where pages lack each security header.
+	DuplicateTitles       []*DuplicateCluster     `json:"duplicateTitles"`       // The largest groups of pages sharing a title.
+	DuplicateDescriptions []*DuplicateCluster     `json:"duplicateDescriptions"` // The largest groups of pages sharing a description.
+	DuplicateH1s          []*DuplicateCluster     `json:"duplicateH1s"`          // The largest groups of pages sharing an h1.
+	TimingByType          map[string]*TimingStats `json:"timingByType"`          // Request timing distributions by URL type.
+	TimingByHost          map[string]*TimingStats `json:"timingByHost"`          // Request timing distributions by host.
+	Queued                []string                `json:"queued"`                // URLs still waiting to be scanned.
+	QueuedCount           int                     `json:"queuedCount"`           // How many URLs were still waiting to be scanned.
+}
+
+// summaryNew is a factory for creating a new Summary instance.
+func summaryNew() *Summary {
+	return &Summary{
+		StatusClasses:         make(map[string]int),
+		URLTypes:              make(map[string]int),
+		ServedTypes:           make(map[string]int),
+		StructuredTypes:       make(map[string]int),
+		Violations:            make(map[string]int),
+		Slowest:               []*SlowURL{},
+		Broken:                []*BrokenTarget{},
+		Canonical:             []*CanonicalPage{},
+		HreflangProblems:      []*HreflangProblem{},
+		BrokenFragments:       []*BrokenFragment{},
+		LinkGraph:             linkGraphNew(),
+		AnchorText:            []*AnchorDistribution{},
+		SecurityHeaders:       []*HeaderCoverage{},
+		DuplicateTitles:       []*DuplicateCluster{},
+		DuplicateDescriptions: []*DuplicateCluster{},
+		DuplicateH1s:          []*DuplicateCluster{},
+		TimingByType:          make(map[string]*TimingStats),
+		TimingByHost:          make(map[string]*TimingStats),
+		Queued:                []string{},
+	}
+}
+
+// statusClass returns the class of a status code ex: 200 => 2xx.
+func statusClass(code int) string {
+	if code < 100 || code > 599 {
+		return "error"
+	}
+	return fmt.Sprintf("%dxx", code/100)
+}
+
+// isBroken returns true if the status code shows the URL could not be loaded.
+func isBroken(code int) bool {
+	return code < 0 || code >= 400
+}
+
+// Summarize aggregates the results of the scan into a Summary.
+func (s *Scanner) Summarize() *Summary {
+	sum := summaryNew()
+	sum.RootURL = s.RootURL.String()
+	sum.StartTime = s.StartTime
+	sum.EndTime = s.EndTime
+	sum.StopReason = s.StopReason
+	sum.Partial = s.StopReason != "" && s.StopReason != StopIdle
+	sum.Requests = s.Requests
+	sum.Queued = append(sum.Queued, s.Queued...)
+	sum.QueuedCount = s.QueuedCount
+	sum.SitemapURLs = len(s.sitemap)
+
+	byType := make(map[string]*timingHistograms)
+	hreflang := make(map[string][]*Alternate)
+	fragments := make(map[string][]string)
+	graph := graphBuilderNew()
+	anchors := make(anchorCounter)
+	headers := make(headerCounter)
+	byHost := make(map[string]*timingHistograms)
+
+	// Each URL is counted once, no matter how many pages refer to it.
+	err := s.Store.EachResult(func(u string, results map[string]*Stats) error {
+		var stat *Stats
+		var duration time.Duration
+		var reused bool
+		parents := make([]string, 0, len(results))
+		for p, st := range results {
+			parents = append(parents, p)
+			reused = reused || st.Reused
+			if stat == nil || st.EndTime.Sub(st.StartTime) > duration {
+				stat = st
+				duration = st.EndTime.Sub(st.StartTime)
+			}
+		}
+		if stat == nil {
+			return nil
+		}
+		sort.Strings(parents)
+		// Hreflang annotations in the sitemaps count as if they were on the page.
+		if stat.isPage() && stat.StatusCode >= 200 && stat.StatusCode <= 299 {
+			if alts := s.alternatesOf(u, stat); len(alts) != len(stat.Hreflang) {
+				c := *stat
+				c.Hreflang = alts
+				stat = &c
+			}
+		}
+
+		if stat.isPage() {
+			sum.Pages++
+			graph.addPage(u, parents, stat.StatusCode >= 200 && stat.StatusCode <= 299)
+			anchors.add(stat)
+			if stat.StatusCode >= 200 && stat.StatusCode <= 299 {
+				headers.add(stat)
+			}
+		} else {
+			sum.Assets++
+		}
+		if reused {
+			sum.Reused++
+		} else {
+			sum.Refetched++
+		}
+		if stat.NoIndex {
+			sum.NoIndex++
+			if stat.isPage() && s.inSitemap(u) {
+				sum.Violations[RuleSitemapNoIndex]++
+			}
+		}
+		if stat.NoFollow {
+			sum.NoFollow++
+		}
+		sum.StatusClasses[statusClass(stat.StatusCode)]++
+		sum.URLTypes[stat.URLType]++
+		if stat.ServedType != "" {
+			sum.ServedTypes[stat.ServedType]++
+		}
+		for _, t := range stat.structuredTypes() {
+			sum.StructuredTypes[t]++
+		}
+		for _, v := range stat.Violations() {
+			sum.Violations[v]++
+		}
+		if stat.Timing.Total > 0 {
+			if byType[stat.URLType] == nil {
+				byType[stat.URLType] = timingHistogramsNew()
+			}
+			byType[stat.URLType].add(stat.Timing)
+			if byHost[stat.URL.Host] == nil {
+				byHost[stat.URL.Host] = timingHistogramsNew()
+			}
+			byHost[stat.URL.Host].add(stat.Timing)
+		}
+		if len(stat.Hreflang) > 0 {
+			hreflang[u] = stat.Hreflang
+		}
+		if len(stat.Fragments) > 0 {
+			fragments[u] = stat.Fragments
+		}
+		if c := canonicalOf(stat); c != "" && c != u {
+			sum.Canonical = append(sum.Canonical, &CanonicalPage{URL: u, Canonical: c})
+		}
+		sum.Slowest = append(sum.Slowest, &SlowURL{
+			URL:      u,
+			URLType:  stat.URLType,
+			Duration: int64(duration / time.Millisecond),
+		})
+		if isBroken(stat.StatusCode) {
+			sum.Broken = append(sum.Broken, &BrokenTarget{
+				URL:            u,
+				URLType:        stat.URLType,
+				StatusCode:     stat.StatusCode,
+				Referrers:      len(parents),
+				ReferringPages: parents,
+			})
+		}
+		// Keep only the worst offenders so memory stays bounded on large sites.
+		if len(sum.Slowest) > 2*summaryMaxSlowest {
+			sort.Stable(slowestSort(sum.Slowest))
+			sum.Slowest = sum.Slowest[:summaryMaxSlowest]
+		}
+		if len(sum.Broken) > 2*summaryMaxBroken {
+			sort.Stable(brokenSort(sum.Broken))
+			sum.Broken = sum.Broken[:summaryMaxBroken]
+		}
+		return nil
+	})
+	if err != nil {
+		s.log.Errorf("Unable to read results: %s", err)
+	}
+
+	// Canonical targets are checked once all the results are in.
+	sum.Canonicalized = len(sum.Canonical)
+	for _, c := range sum.Canonical {
+		c.Problems = s.canonicalProblems(c.Canonical)
+		for _, p := range c.Problems {
+			sum.Violations[p]++
+		}
+	}
+	sort.Stable(canonicalSort(sum.Canonical))
+	if len(sum.Canonical) > summaryMaxCanonical {
+		sum.Canonical = sum.Canonical[:summaryMaxCanonical]
+	}
+	sum.HreflangPages = len(hreflang)
+	s.checkHreflang(sum, hreflang)
+	s.checkFragments(sum, fragments)
+	s.findDuplicates(sum)
+	sum.LinkGraph = graph.analyze(s.RootURL.String())
+	sum.AnchorText = anchors.distributions()
+	sum.SecurityHeaders = headers.coverage()
+	for t, h := range byType {
+		sum.TimingByType[t] = h.stats()
+	}
+	for host, h := range byHost {
+		sum.TimingByHost[host] = h.stats()
+	}
+	sort.Stable(slowestSort(sum.Slowest))
+	if len(sum.Slowest) > summaryMaxSlowest {
+		sum.Slowest = sum.Slowest[:summaryMaxSlowest]
+	}
+	sort.Stable(brokenSort(sum.Broken))
+	if len(sum.Broken) > summaryMaxBroken {
+		sum.Broken = sum.Broken[:summaryMaxBroken]
+	}
+	return sum
+}
+
+// slowestSort orders slow URLs by descending duration.
+type slowestSort []*SlowURL
+
+func (s slowestSort) Len() int           { return len(s) }
+func (s slowestSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s slowestSort) Less(i, j int) bool { return s[i].Duration > s[j].Duration }
+
+// brokenSort orders broken targets by descending number of referrers.
+type brokenSort []*BrokenTarget
+
+func (s brokenSort) Len() int           { return len(s) }
+func (s brokenSort) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s brokenSort) Less(i, j int) bool { return s[i].Referrers > s[j].Referrers }
+
+// sortedKeys returns the keys of a count map in alphabetical order.
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortedTimingKeys returns the keys of a timing map in alphabetical order.
+func sortedTimingKeys(m map[string]*TimingStats) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Text returns the summary as human readable text.
+func (s *Summary) Text() string {
+	var b bytes.Buffer
+	if s.Partial {
+		fmt.Fprintf(&b, "Partial scan summary for %s\n", s.RootURL)
+	} else {
+		fmt.Fprintf(&b, "Scan summary for %s\n", s.RootURL)
+	}
+	fmt.Fprintf(&b, "  Duration: %s (ended: %s)\n", s.EndTime.Sub(s.StartTime), s.StopReason)
+	fmt.Fprintf(&b, "  Scanned: %d pages, %d assets (%d requests)\n", s.Pages, s.Assets, s.Requests)
+	fmt.Fprintf(&b, "  Reused: %d, refetched: %d\n", s.Reused, s.Refetched)
+	fmt.Fprintf(&b, "  Robots: %d noindex, %d nofollow\n", s.NoIndex, s.NoFollow)
+	fmt.Fprintf(&b, "  Sitemaps: %d URLs listed\n", s.SitemapURLs)
+	fmt.Fprintf(&b, "  Status:\n")
+	for _, k := range sortedKeys(s.StatusClasses) {
+		fmt.Fprintf(&b, "    %-20s %d\n", k, s.StatusClasses[k])
+	}
+	fmt.Fprintf(&b, "  URL types:\n")
+	for _, k := range sortedKeys(s.URLTypes) {
+		fmt.Fprintf(&b, "    %-20s %d\n", k, s.URLTypes[k])
+	}
+	fmt.Fprintf(&b, "  Served types:\n")
+	for _, k := range sortedKeys(s.ServedTypes) {
+		fmt.Fprintf(&b, "    %-20s %d\n", k, s.ServedTypes[k])
+	}
+	fmt.Fprintf(&b, "  Structured data types:\n")
+	for _, k := range sortedKeys(s.StructuredTypes) {
+		fmt.Fprintf(&b, "    %-20s %d\n", k, s.StructuredTypes[k])
+	}
+	fmt.Fprintf(&b, "  Violations:\n")
+	for _, k := range sortedKeys(s.Violations) {
+		fmt.Fprintf(&b, "    %-20s %d\n", k, s.Violations[k])
+	}
+	fmt.Fprintf(&b, "  Timing by URL type (ms, first byte p50/p90/p99, total p50/p90/p99):\n")
+	for _, k := range sortedTimingKeys(s.TimingByType) {
+		fmt.Fprintf(&b, "    %-20s %s\n", k, s.TimingByType[k].Text())
+	}
+	fmt.Fprintf(&b, "  Timing by host (ms, first byte p50/p90/p99, total p50/p90/p99):\n")
+	for _, k := range sortedTimingKeys(s.TimingByHost) {
+		fmt.Fprintf(&b, "    %-20s %s\n", k, s.TimingByHost[k].Text())
+	}
+	fmt.Fprintf(&b, "  Slowest:\n")
+	for _, u := range s.Slowest {
+		fmt.Fprintf(&b, "    %6dms %s\n", u.Duration, u.URL)
+	}
+	fmt.Fprintf(&b, "  Broken:\n")
+	for _, u := range s.Broken {
+		fmt.Fprintf(&b, "    %4d %3d refs %s\n", u.StatusCode, u.Referrers, u.URL)
+	}
+	fmt.Fprintf(&b, "  Canonicalized: %d\n", s.Canonicalized)
+	for _, c := range s.Canonical {
+		fmt.Fprintf(&b, "    %s => %s %v\n", c.URL, c.Canonical, c.Problems)
+	}
+	fmt.Fprintf(&b, "  Hreflang: %d pages, %d problems\n", s.HreflangPages, s.HreflangProblemCount)
+	for _, h := range s.HreflangProblems {
+		fmt.Fprintf(&b, "    %s %s => %s %v\n", h.URL, h.Lang, h.Target, h.Problems)
+	}
+	fmt.Fprintf(&b, "  Broken fragments: %d\n", s.BrokenFragmentCount)
+	for _, f := range s.BrokenFragments {
+		fmt.Fprintf(&b, "    %3d refs %s\n", f.Referrers, f.URL)
+	}
+	g := s.LinkGraph
+	fmt.Fprintf(&b, "  Link graph: %d pages, %d links, max depth %d, %d unreachable\n",
+		g.Pages, g.Links, g.MaxDepth, g.Unreachable)
+	for d := 0; d <= g.MaxDepth; d++ {
+		fmt.Fprintf(&b, "    depth %-14d %d\n", d, g.Depths[d])
+	}
+	fmt.Fprintf(&b, "  Deep pages (more than %d clicks): %d\n", graphDeepClicks, g.DeepPageCount)
+	for _, p := range g.DeepPages {
+		fmt.Fprintf(&b, "    %3d clicks %s\n", p.Depth, p.URL)
+	}
+	fmt.Fprintf(&b, "  Dead ends: %d\n", g.DeadEndCount)
+	for _, p := range g.DeadEnds {
+		fmt.Fprintf(&b, "    %3d inlinks %s\n", p.Inlinks, p.URL)
+	}
+	fmt.Fprintf(&b, "  Top PageRank:\n")
+	for _, p := range g.TopPageRank {
+		fmt.Fprintf(&b, "    %.4f %3d inlinks %s\n", p.PageRank, p.Inlinks, p.URL)
+	}
+	fmt.Fprintf(&b, "  Anchor text:\n")
+	for _, d := range s.AnchorText {
+		fmt.Fprintf(&b, "    %4d links %s\n", d.Links, d.URL)
+		for _, t := range d.Texts {
+			fmt.Fprintf(&b, "      %4d %q\n", t.Count, t.Text)
+		}
+	}
+	fmt.Fprintf(&b, "  Security headers (pages missing):\n")
+	for _, h := range s.SecurityHeaders {
+		fmt.Fprintf(&b, "    %-26s %d\n", h.Header, h.Missing)
+		for _, p := range h.Paths {
+			fmt.Fprintf(&b, "      %4d %s\n", p.Count, p.Path)
+		}
+	}
+	for _, d := range []struct {
+		name     string
+		clusters []*DuplicateCluster
+	}{
+		{"titles", s.DuplicateTitles},
+		{"descriptions", s.DuplicateDescriptions},
+		{"h1s", s.DuplicateH1s},
+	} {
+		fmt.Fprintf(&b, "  Duplicate %s:\n", d.name)
+		for _, c := range d.clusters {
+			fmt.Fprintf(&b, "    %4d %q\n", c.Count, c.Text)
+		}
+	}
+	fmt.Fprintf(&b, "  Queued: %d\n", s.QueuedCount)
+	for _, u := range s.Queued {
+		fmt.Fprintf(&b, "    %s\n", u)
+	}
+	return b.String()
+}
+
+// String is an implentation of the Stringer interface so the structure is returned as a
+// string to fmt.Print() etc.
+func (s *Summary) String() string {
+	j, _ := json.Marshal(s)
+	return string(j)
+}
@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testSummaryStatusClass = []struct {
+		code     int
+		expected string
+	}{
+		{200, "2xx"},
+		{301, "3xx"},
+		{404, "4xx"},
+		{503, "5xx"},
+		{-1, "error"},
+	}
+)
+
+// testSummaryStat creates a result and stores it in the scanner.
+func testSummaryStat(s *Scanner, rawurl string, ut string, parent string, status int, d time.Duration) {
+	u, _ := url.Parse(rawurl)
+	p, _ := url.Parse(parent)
+	st := StatsNew(u, ut, p)
+	st.StatusCode = status
+	st.StartTime = time.Now()
+	st.EndTime = st.StartTime.Add(d)
+	s.Store.PutResult(st)
+}
+
+func TestSummaryStatusClass(t *testing.T) {
+	t.Parallel()
+	for _, tc := range testSummaryStatusClass {
+		if c := statusClass(tc.code); c != tc.expected {
+			t.Errorf("Invalid status class for %d. Expected: %s Received: %s", tc.code, tc.expected, c)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	s.StopReason = StopIdle
+	s.Queued = append(s.Queued, "http://example.com/queued")
+	testSummaryStat(s, "http://example.com", "html", "http:", 200, 10*time.Millisecond)
+	testSummaryStat(s, "http://example.com/a", "html", "http://example.com", 200, 30*time.Millisecond)
+	testSummaryStat(s, "http://example.com/a", "html", "http://example.com/b", 200, 20*time.Millisecond)
+	testSummaryStat(s, "http://example.com/b", "html", "http://example.com", 200, time.Millisecond)
+	testSummaryStat(s, "http://example.com/missing", "html", "http://example.com", 404, time.Millisecond)
+	testSummaryStat(s, "http://example.com/x.jpg", "img", "http://example.com", 500, time.Millisecond)
+	testSummaryStat(s, "http://example.com/x.jpg", "img", "http://example.com/a", 500, time.Millisecond)
+	testSummaryStat(s, "http://example.com/x.jpg", "img", "http://example.com/b", 500, time.Millisecond)
+
+	sum := s.Summarize()
+	if sum.StopReason != StopIdle {
+		t.Errorf("Invalid stop reason: %s", sum.StopReason)
+	}
+	if sum.Pages != 4 || sum.Assets != 1 {
+		t.Errorf("Invalid counts. Pages: %d Assets: %d", sum.Pages, sum.Assets)
+	}
+	if sum.StatusClasses["2xx"] != 3 || sum.StatusClasses["4xx"] != 1 || sum.StatusClasses["5xx"] != 1 {
+		t.Errorf("Invalid status classes: %v", sum.StatusClasses)
+	}
+	if sum.URLTypes["html"] != 4 || sum.URLTypes["img"] != 1 {
+		t.Errorf("Invalid url types: %v", sum.URLTypes)
+	}
+	if sum.Violations[RuleCanonicalMissing] != 3 {
+		t.Errorf("Invalid violations: %v", sum.Violations)
+	}
+	if len(sum.Slowest) != 5 || sum.Slowest[0].URL != "http://example.com/a" || sum.Slowest[0].Duration != 30 {
+		t.Errorf("Invalid slowest URLs.")
+	}
+	if len(sum.Broken) != 2 || sum.Broken[0].URL != "http://example.com/x.jpg" ||
+		sum.Broken[0].Referrers != 3 || len(sum.Broken[0].ReferringPages) != 3 {
+		t.Errorf("Invalid broken targets.")
+	}
+	if len(sum.Queued) != 1 {
+		t.Errorf("Invalid queued URLs.")
+	}
+	if !strings.Contains(sum.Text(), "http://example.com/queued") {
+		t.Errorf("Queued URL should have been printed.")
+	}
+	if !strings.Contains(sum.String(), `"stopReason":"idle"`) {
+		t.Errorf("Stop reason should have been encoded.")
+	}
+}
+
+func TestSummarizeTiming(t *testing.T) {
+	t.Parallel()
+	s := New("example.com", testMaxRunMin, testMaxWorkers)
+	for i, raw := range []string{"http://example.com", "http://example.com/a", "http://cdn.example.com/x.jpg"} {
+		u, _ := url.Parse(raw)
+		st := StatsNew(u, "html", u)
+		if i == 2 {
+			st.URLType = "img"
+		}
+		st.Timing = Timing{FirstByte: time.Duration(i+1) * time.Millisecond, Total: time.Duration(i+2) * time.Millisecond}
+		s.Store.PutResult(st)
+	}
+	sum := s.Summarize()
+	if sum.TimingByType["html"] == nil || sum.TimingByType["html"].Total.Count != 2 ||
+		sum.TimingByType["img"] == nil || sum.TimingByType["img"].Total.Count != 1 {
+		t.Errorf("Invalid timing by type: %v", sum.TimingByType)
+	}
+	if sum.TimingByHost["example.com"] == nil || sum.TimingByHost["example.com"].FirstByte.Count != 2 ||
+		sum.TimingByHost["cdn.example.com"] == nil {
+		t.Errorf("Invalid timing by host: %v", sum.TimingByHost)
+	}
+	if !strings.Contains(sum.Text(), "cdn.example.com") {
+		t.Errorf("Timing should be in the text summary.")
+	}
+}
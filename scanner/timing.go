@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"math"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	timingBucketGrowth = 1.05 // Each timing histogram bucket is this much wider than the last.
+)
+
+// Percentiles used for request timing distributions.
+var (
+	timingPercentiles = []float64{0.5, 0.9, 0.99}
+)
+
+// Timing is how long each phase of a request took. Phases that didn't happen, such as the
+// DNS lookup on a reused connection, are zero.
+type Timing struct {
+	DNS       time.Duration `json:"dns"`       // The DNS lookup.
+	Connect   time.Duration `json:"connect"`   // The TCP connect.
+	TLS       time.Duration `json:"tls"`       // The TLS handshake.
+	FirstByte time.Duration `json:"firstByte"` // From the start of the request to the first response byte.
+	Download  time.Duration `json:"download"`  // From the first response byte to the end of the body.
+	Total     time.Duration `json:"total"`     // From the start of the request to the end of the body.
+}
+
+// requestTrace records when the phases of a request happen.
+type requestTrace struct {
+	mu           sync.Mutex    // For locking access. Trace hooks may be called from other go routines.
+	start        time.Time     // When the request started.
+	dnsStart     time.Time     // When the DNS lookup started.
+	dns          time.Duration // How long the DNS lookup took.
+	connectStart time.Time     // When the TCP connect started.
+	connect      time.Duration // How long the TCP connect took.
+	tlsStart     time.Time     // When the TLS handshake started.
+	tls          time.Duration // How long the TLS handshake took.
+	firstByte    time.Time     // When the first response byte arrived.
+}
+
+// traceContext returns a context that records the phases of a request in a new trace.
+func traceContext(ctx context.Context) (context.Context, *requestTrace) {
+	t := &requestTrace{start: time.Now()}
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.elapsed(t.dnsStart, &t.dns) },
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.elapsed(t.connectStart, &t.connect)
+		},
+		TLSHandshakeStart: func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.elapsed(t.tlsStart, &t.tls)
+		},
+		GotFirstResponseByte: func() { t.mark(&t.firstByte) },
+	}), t
+}
+
+// mark records the current time.
+func (t *requestTrace) mark(tm *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*tm = time.Now()
+}
+
+// elapsed records the time since a phase started.
+func (t *requestTrace) elapsed(start time.Time, d *time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*d = time.Since(start)
+}
+
+// timing returns the duration of each phase of a request whose body was finished at end.
+func (t *requestTrace) timing(end time.Time) Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tm := Timing{
+		DNS:     t.dns,
+		Connect: t.connect,
+		TLS:     t.tls,
+		Total:   end.Sub(t.start),
+	}
+	if !t.firstByte.IsZero() {
+		tm.FirstByte = t.firstByte.Sub(t.start)
+		tm.Download = end.Sub(t.firstByte)
+	}
+	return tm
+}
+
+// Percentiles is a distribution of durations in milliseconds.
+type Percentiles struct {
+	Count int     `json:"count"` // The number of durations.
+	P50   float64 `json:"p50"`   // The median.
+	P90   float64 `json:"p90"`   // The 90th percentile.
+	P99   float64 `json:"p99"`   // The 99th percentile.
+	Max   float64 `json:"max"`   // The longest duration.
+}
+
+// TimingStats are the distributions of the request phases of a group of URLs.
+type TimingStats struct {
+	DNS       *Percentiles `json:"dns"`       // DNS lookups.
+	Connect   *Percentiles `json:"connect"`   // TCP connects.
+	TLS       *Percentiles `json:"tls"`       // TLS handshakes.
+	FirstByte *Percentiles `json:"firstByte"` // Time to first byte.
+	Download  *Percentiles `json:"download"`  // Body downloads.
+	Total     *Percentiles `json:"total"`     // Whole requests.
+}
+
+// durationHistogram counts durations in buckets that grow exponentially so percentiles
+// can be estimated, within a few percent, in bounded memory on large sites.
+type durationHistogram struct {
+	counts map[int]int   // Count of durations by bucket.
+	n      int           // The number of durations.
+	max    time.Duration // The longest duration.
+}
+
+// durationHistogramNew is a factory for creating a new durationHistogram instance.
+func durationHistogramNew() *durationHistogram {
+	return &durationHistogram{counts: make(map[int]int)}
+}
+
+// bucket returns the bucket of a duration. Bucket 0 holds durations under a microsecond.
+func (h *durationHistogram) bucket(d time.Duration) int {
+	if d < time.Microsecond {
+		return 0
+	}
+	return 1 + int(math.Log(float64(d)/float64(time.Microsecond))/math.Log(timingBucketGrowth))
+}
+
+// bound returns the upper bound of a bucket.
+func (h *durationHistogram) bound(b int) time.Duration {
+	if b == 0 {
+		return time.Microsecond
+	}
+	return time.Duration(math.Pow(timingBucketGrowth, float64(b)) * float64(time.Microsecond))
+}
+
+// add counts a duration.
+func (h *durationHistogram) add(d time.Duration) {
+	h.counts[h.bucket(d)]++
+	h.n++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// percentile returns the estimated duration that p of the durations are shorter than or
+// equal to ex: 0.5 => the median.
+func (h *durationHistogram) percentile(p float64) time.Duration {
+	buckets := make([]int, 0, len(h.counts))
+	for b := range h.counts {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+	rank := int(math.Ceil(p * float64(h.n)))
+	var seen int
+	for _, b := range buckets {
+		seen += h.counts[b]
+		if seen >= rank {
+			if d := h.bound(b); d < h.max {
+				return d
+			}
+			return h.max
+		}
+	}
+	return h.max
+}
+
+// percentiles returns the distribution of the durations.
+func (h *durationHistogram) percentiles() *Percentiles {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	p := &Percentiles{Count: h.n}
+	if h.n == 0 {
+		return p
+	}
+	p.P50 = ms(h.percentile(timingPercentiles[0]))
+	p.P90 = ms(h.percentile(timingPercentiles[1]))
+	p.P99 = ms(h.percentile(timingPercentiles[2]))
+	p.Max = ms(h.max)
+	return p
+}
+
+// timingHistograms collect the request phases of a group of URLs.
+type timingHistograms struct {
+	dns, connect, tls, firstByte, download, total *durationHistogram
+}
+
+// timingHistogramsNew is a factory for creating a new timingHistograms instance.
+func timingHistogramsNew() *timingHistograms {
+	return &timingHistograms{
+		dns:       durationHistogramNew(),
+		connect:   durationHistogramNew(),
+		tls:       durationHistogramNew(),
+		firstByte: durationHistogramNew(),
+		download:  durationHistogramNew(),
+		total:     durationHistogramNew(),
+	}
+}
+
+// add counts the phases of a request. Phases that didn't happen are not counted.
+func (h *timingHistograms) add(t Timing) {
+	for _, p := range []struct {
+		hist *durationHistogram
+		d    time.Duration
+	}{
+		{h.dns, t.DNS},
+		{h.connect, t.Connect},
+		{h.tls, t.TLS},
+		{h.firstByte, t.FirstByte},
+		{h.download, t.Download},
+		{h.total, t.Total},
+	} {
+		if p.d > 0 {
+			p.hist.add(p.d)
+		}
+	}
+}
+
+// stats returns the distributions of the request phases.
+func (h *timingHistograms) stats() *TimingStats {
+	return &TimingStats{
+		DNS:       h.dns.percentiles(),
+		Connect:   h.connect.percentiles(),
+		TLS:       h.tls.percentiles(),
+		FirstByte: h.firstByte.percentiles(),
+		Download:  h.download.percentiles(),
+		Total:     h.total.percentiles(),
+	}
+}
+
+// Text returns the time to first byte and total time percentiles as human readable text.
+func (t *TimingStats) Text() string {
+	return fmt.Sprintf("%.1f/%.1f/%.1f  %.1f/%.1f/%.1f",
+		t.FirstByte.P50, t.FirstByte.P90, t.FirstByte.P99, t.Total.P50, t.Total.P90, t.Total.P99)
+}
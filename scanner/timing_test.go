@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDurationHistogram(t *testing.T) {
+	t.Parallel()
+	h := durationHistogramNew()
+	for i := 1; i <= 100; i++ {
+		h.add(time.Duration(i) * time.Millisecond)
+	}
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{0.5, 50 * time.Millisecond},
+		{0.9, 90 * time.Millisecond},
+		{0.99, 99 * time.Millisecond},
+		{1, 100 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		d := h.percentile(tc.p)
+		if math.Abs(float64(d-tc.expected)) > float64(tc.expected)*(timingBucketGrowth-1) {
+			t.Errorf("Invalid percentile %.2f. Expected about %s, received %s.", tc.p, tc.expected, d)
+		}
+	}
+	p := h.percentiles()
+	if p.Count != 100 || p.Max != 100 {
+		t.Errorf("Invalid percentiles: %+v", p)
+	}
+	if p := durationHistogramNew().percentiles(); p.Count != 0 || p.P50 != 0 {
+		t.Errorf("Empty histogram should have no percentiles: %+v", p)
+	}
+}
+
+func TestTimingHistogramsSkipZero(t *testing.T) {
+	t.Parallel()
+	h := timingHistogramsNew()
+	h.add(Timing{DNS: time.Millisecond, FirstByte: 2 * time.Millisecond, Total: 3 * time.Millisecond})
+	h.add(Timing{FirstByte: 2 * time.Millisecond, Total: 3 * time.Millisecond})
+	st := h.stats()
+	if st.DNS.Count != 1 || st.Connect.Count != 0 || st.Total.Count != 2 {
+		t.Errorf("Phases that didn't happen should not be counted.")
+	}
+}
+
+func TestFetchTiming(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "<html></html>")
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	u, _ := url.Parse(srvr.URL)
+	j := scanJobNew(u, "html", nil)
+	resp, err := fetch(context.Background(), &http.Client{}, j)
+	if err != nil {
+		t.Fatalf("Error fetching: %s", err)
+	}
+	drain(resp.Body)
+	tm := j.trace.timing(time.Now())
+	if tm.Connect <= 0 || tm.FirstByte <= 0 || tm.Total < tm.FirstByte {
+		t.Errorf("Invalid timing: %+v", tm)
+	}
+	if tm.Download < 20*time.Millisecond {
+		t.Errorf("Download should cover the whole body: %+v", tm)
+	}
+}
@@ -16,6 +16,33 @@ Server options:
 	                                 machine (default 1).
     -m, --minutes MAX                MAX minutes to live (default: 5).
     -W, --workers MAX                MAX running workers allowed (default: 4).
+    -r, --report FILE                FILE to write the json report to.
+    -c, --checkpoint FILE            FILE to periodically save the state of
+                                     the scan to.
+    -i, --interval SEC               SEC between checkpoints (default: 60).
+    -R, --resume                     Resume the scan from the checkpoint file.
+    -s, --store FILE                 Database FILE to hold the crawl on disk
+                                     instead of in memory.
+    -I, --history FILE               Database FILE of previous runs. URLs not
+                                     modified since are not downloaded again.
+    -z, --max-size LIMITS            Maximum body size for each URL type
+                                     ex: "html=10M,img=512K", 0 for no limit,
+                                     * for every other type (default:
+                                     *=20M,css=5M,html=10M,img=20M,js=5M).
+    -t, --timeout SEC                SEC a request may take, including
+                                     reading the body (default: 60).
+    -a, --agent NAME                 NAME of the bot whose robots directives
+                                     apply (default: googlebot).
+    -n, --nofollow                   Don't follow links on pages marked
+                                     nofollow.
+    -d, --near                       Report near identical titles,
+                                     descriptions and h1s as duplicates.
+    -g, --graph FILE                 FILE to export the link graph to, as
+                                     GraphML if it ends in .graphml, else DOT.
+    -p, --graph-pages                Export only internal html pages.
+    -D, --graph-dirs                 Collapse exported URLs into their
+                                     directories.
+    -C, --graph-color                Colour exported nodes by status.
 
 Common options:
     -h, --help                       Show this message.
@@ -1,6 +1,7 @@
 package scanner
 
 import (
+	"context"
 	"net/http"
 	"sync"
 	"time"
@@ -10,11 +11,20 @@ var (
 	workerMaxSleep = 250 * time.Millisecond // How long should a worker sleep between jobq peeks.
 )
 
+// workerOptions are the settings of the scanner that the workers need.
+type workerOptions struct {
+	maxBodySize SizeLimits    // The maximum number of body bytes read for each URL type.
+	timeout     time.Duration // The deadline of each request, including reading the body.
+	robotsAgent string        // The bot whose robots directives apply as well as the generic ones.
+}
+
 // scanWorker is used as a go routine wrapper to handle URL scan jobs.
-func scanWorker(jobq chan *scanJob, doneCh chan *scanJob, wg *sync.WaitGroup) {
+func scanWorker(ctx context.Context, jobq chan *scanJob, doneCh chan *scanJob, wg *sync.WaitGroup,
+	opts workerOptions) {
 	defer wg.Done()
-	cl := &http.Client{}
+	cl := &http.Client{Timeout: opts.timeout}
 	a := bodyAnalyzerNew(nil)
+	a.robotsAgent = opts.robotsAgent
 	for {
 		select {
 		case j, ok := <-jobq:
@@ -23,18 +33,55 @@ func scanWorker(jobq chan *scanJob, doneCh chan *scanJob, wg *sync.WaitGroup) {
 			}
 			// Scan the link.
 			j.Stat.StartTime = time.Now()
-			resp, err := cl.Get(j.Stat.URL.String())
-			j.Stat.EndTime = time.Now()
+			resp, err := fetch(ctx, cl, j)
+			if ctx.Err() != nil {
+				if err == nil {
+					drain(resp.Body)
+				}
+				continue // Cancelled while stopping. The job is left pending.
+			}
 			if err != nil {
 				j.Stat.StatusCode = -1 // We couldn't even get a HTTP status code.
+			} else if resp.StatusCode == http.StatusNotModified && j.Prev != nil {
+				reuse(j)
 			} else {
 				j.Stat.StatusCode = resp.StatusCode
-				if j.Stat.URLType == "html" {
-					j.Body = resp.Body
+				j.Stat.ETag = resp.Header.Get("ETag")
+				j.Stat.LastModified = resp.Header.Get("Last-Modified")
+				j.Stat.ContentType = resp.Header.Get("Content-Type")
+				j.Stat.ContentLength = resp.ContentLength
+				// Relative URLs on the page are resolved against where it was served from.
+				if u := resp.Request.URL.String(); u != j.Stat.URL.String() {
+					j.Stat.RedirectURL = u
+				}
+				resp.Body = limitBody(j, resp.Body, opts.maxBodySize.limit(j.Stat.URLType))
+				resp.Body = classify(j, resp)
+				if j.Stat.ServedType == servedHTML && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+					auditSecurityHeaders(j, resp.Header)
+				}
+				// Only pages served as html and stylesheets served as css are analyzed.
+				switch {
+				case isPageType(j.Stat.URLType) && j.Stat.ServedType == servedHTML:
+					j.Body = decode(j, j.Stat.ContentType, resp.Body)
 					a.ScanJob = j
 					a.analyzeBody()
-					j.Body.Close()
+				case j.Stat.URLType == "css" && j.Stat.ServedType == servedCSS:
+					analyzeCSS(j, resp.Body)
 				}
+				for _, v := range resp.Header["X-Robots-Tag"] {
+					j.robots.addHeader(v, opts.robotsAgent)
+				}
+				j.robots.apply(j.Stat)
+				checkCanonical(j, resp.Header)
+				hreflangHeader(j, resp.Header)
+			}
+			if err == nil {
+				drain(resp.Body)
+			}
+			// Timing covers the whole download, not just the headers.
+			j.Stat.EndTime = time.Now()
+			if j.trace != nil {
+				j.Stat.Timing = j.trace.timing(j.Stat.EndTime)
 			}
 			doneCh <- j
 		default:
@@ -42,3 +89,16 @@ func scanWorker(jobq chan *scanJob, doneCh chan *scanJob, wg *sync.WaitGroup) {
 		}
 	}
 }
+
+// reuse copies the result of the previous run into a job for a URL that has not been
+// modified. Only the identity and start time of this scan are kept.
+func reuse(j *scanJob) {
+	st := *j.Prev.Stat
+	st.URL = j.Stat.URL
+	st.URLType = j.Stat.URLType
+	st.ParentURL = j.Stat.ParentURL
+	st.StartTime = j.Stat.StartTime
+	st.Reused = true
+	*j.Stat = st
+	j.Children = append(j.Children, j.Prev.Children...)
+}
@@ -1,10 +1,237 @@
 package scanner
 
 import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestScanWorker(t *testing.T) {
 	t.Parallel()
 	t.Skipf("Covered by TestScanRun")
 }
+
+func TestScanWorkerConditional(t *testing.T) {
+	t.Parallel()
+	const etag = `"v1"`
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		io.WriteString(w, `<h1>Title</h1><a href="/new">new</a>`)
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	var wg sync.WaitGroup
+	jobq := make(chan *scanJob, 2)
+	doneCh := make(chan *scanJob, 2)
+	wg.Add(1)
+	go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{})
+
+	u, _ := url.Parse(srvr.URL)
+	jobq <- scanJobNew(u, "html", nil)
+	j := <-doneCh
+	if j.Stat.StatusCode != http.StatusOK || j.Stat.ETag != etag || j.Stat.Reused {
+		t.Errorf("First scan should have downloaded the page.")
+	}
+
+	old, _ := url.Parse("/old")
+	prev := StatsNew(u, "html", nil)
+	prev.StatusCode = http.StatusOK
+	prev.ETag = etag
+	prev.H1Count = 2
+	k := scanJobNew(u, "html", nil)
+	k.Prev = &historyEntry{Stat: prev, Children: []*scanJobChild{{URL: old, URLType: "html"}}}
+	jobq <- k
+	k = <-doneCh
+	close(jobq)
+	wg.Wait()
+	if !k.Stat.Reused || k.Stat.StatusCode != http.StatusOK || k.Stat.H1Count != 2 {
+		t.Errorf("Unmodified page should have reused the previous result.")
+	}
+	if k.Stat.StartTime.IsZero() || k.Stat.URL != u {
+		t.Errorf("Reused result should keep the identity and timing of this scan.")
+	}
+	if len(k.Children) != 1 || k.Children[0].URL.Path != "/old" {
+		t.Errorf("Unmodified page should have reused the previous links.")
+	}
+}
+
+func TestScanWorkerBodySize(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("<p>Text</p>", 10))
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	tests := []struct {
+		limit       int64
+		expected    int64
+		expectedErr bool
+		message     string
+	}{
+		{0, 110, false, "Unlimited body should have been read in full."},
+		{110, 110, false, "Body of exactly the limit should not have been flagged."},
+		{50, 51, true, "Body larger than the limit should have been flagged."},
+	}
+	for _, tc := range tests {
+		var wg sync.WaitGroup
+		jobq := make(chan *scanJob, 1)
+		doneCh := make(chan *scanJob, 1)
+		wg.Add(1)
+		go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{maxBodySize: SizeLimits{"html": tc.limit}})
+		u, _ := url.Parse(srvr.URL)
+		jobq <- scanJobNew(u, "html", nil)
+		j := <-doneCh
+		close(jobq)
+		wg.Wait()
+		if j.Stat.Transferred != tc.expected {
+			t.Errorf("%s Expected %d bytes, received %d.", tc.message, tc.expected, j.Stat.Transferred)
+		}
+		if j.Stat.hasIssue(RuleBodySize) != tc.expectedErr {
+			t.Errorf("%s Issues: %v", tc.message, j.Stat.Issues)
+		}
+	}
+}
+
+func TestScanWorkerTimeout(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(500 * time.Millisecond)
+		}
+		io.WriteString(w, "<p>Text</p>")
+		w.(http.Flusher).Flush()
+		time.Sleep(500 * time.Millisecond)
+		io.WriteString(w, "<p>More</p>")
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	tests := []struct {
+		path           string
+		expectedStatus int
+		expected       int64
+		message        string
+	}{
+		{"/trickle", http.StatusOK, 11, "Body should have been cut short by the timeout."},
+		{"/slow", -1, 0, "Request should have timed out."},
+	}
+	for _, tc := range tests {
+		var wg sync.WaitGroup
+		jobq := make(chan *scanJob, 1)
+		doneCh := make(chan *scanJob, 1)
+		wg.Add(1)
+		go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{timeout: 200 * time.Millisecond})
+		u, _ := url.Parse(srvr.URL + tc.path)
+		jobq <- scanJobNew(u, "html", nil)
+		j := <-doneCh
+		close(jobq)
+		wg.Wait()
+		if j.Stat.StatusCode != tc.expectedStatus || j.Stat.Transferred != tc.expected {
+			t.Errorf("%s Status: %d Bytes: %d", tc.message, j.Stat.StatusCode, j.Stat.Transferred)
+		}
+		if j.Stat.hasIssue(RuleTimeout) != (tc.expectedStatus == http.StatusOK) {
+			t.Errorf("%s Issues: %v", tc.message, j.Stat.Issues)
+		}
+	}
+}
+
+func TestScanWorkerRobots(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Add("X-Robots-Tag", "otherbot: noindex")
+		w.Header().Add("X-Robots-Tag", "testbot: nofollow")
+		io.WriteString(w, `<html><meta name="robots" content="noindex"><h1>Page</h1></html>`)
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	var wg sync.WaitGroup
+	jobq := make(chan *scanJob, 1)
+	doneCh := make(chan *scanJob, 1)
+	wg.Add(1)
+	go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{robotsAgent: "TestBot"})
+	u, _ := url.Parse(srvr.URL)
+	jobq <- scanJobNew(u, "html", nil)
+	j := <-doneCh
+	close(jobq)
+	wg.Wait()
+	if !j.Stat.NoIndex || !j.Stat.NoFollow {
+		t.Errorf("Meta and header directives should have been recorded: %v", j.Stat)
+	}
+}
+
+func TestScanWorkerCSS(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		io.WriteString(w, `@import "print.css"; body { background: url(bg.png) }`)
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	var wg sync.WaitGroup
+	jobq := make(chan *scanJob, 1)
+	doneCh := make(chan *scanJob, 1)
+	wg.Add(1)
+	go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{})
+	u, _ := url.Parse(srvr.URL + "/css/site.css")
+	jobq <- scanJobNew(u, "css", nil)
+	j := <-doneCh
+	close(jobq)
+	wg.Wait()
+	if j.Stat.Method != "GET" || len(j.Children) != 2 {
+		t.Errorf("Stylesheet should have been downloaded and analyzed: %s %d", j.Stat.Method, len(j.Children))
+	}
+}
+
+func TestScanWorkerSecurityHeaders(t *testing.T) {
+	t.Parallel()
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "unsafe-url")
+		if r.URL.Path == "/logo.png" {
+			w.Header().Set("Content-Type", "image/png")
+			return
+		}
+		io.WriteString(w, "<html><h1>Page</h1></html>")
+	}
+	srvr := httptest.NewServer(http.HandlerFunc(h))
+	defer srvr.Close()
+
+	var wg sync.WaitGroup
+	jobq := make(chan *scanJob, 2)
+	doneCh := make(chan *scanJob, 2)
+	wg.Add(1)
+	go scanWorker(context.Background(), jobq, doneCh, &wg, workerOptions{})
+	u, _ := url.Parse(srvr.URL)
+	jobq <- scanJobNew(u, "html", nil)
+	page := <-doneCh
+	u, _ = url.Parse(srvr.URL + "/logo.png")
+	jobq <- scanJobNew(u, "img", nil)
+	img := <-doneCh
+	close(jobq)
+	wg.Wait()
+	expected := []string{RuleReferrerWeak, RulePermissionsMissing}
+	if !reflect.DeepEqual(page.Stat.Issues, expected) || len(page.Stat.SecurityHeaders) != 3 {
+		t.Errorf("Security headers should have been audited. Issues: %v Headers: %v",
+			page.Stat.Issues, page.Stat.SecurityHeaders)
+	}
+	if len(img.Stat.Issues) != 0 || len(img.Stat.SecurityHeaders) != 0 {
+		t.Errorf("Only html responses should be audited: %v", img.Stat.Issues)
+	}
+}